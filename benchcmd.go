@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/josledp/goprompt/prompt"
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+const benchUsage = `usage: goprompt bench [-n runs] [-dir dir] [-file template] [-baseline file] [-save file] [-threshold percent] [name]
+
+Renders a template several times in a directory and reports the p50/p95/p99 latency
+overall and of every plugin. It renders the template file given with -file, the
+template called name (predefined or in ~/.config/goprompt/templates), or the
+configured one. With -baseline it shows the change from a report saved with -save
+and fails if any p95 latency is more than -threshold percent higher`
+
+//runBench runs the bench subcommand and returns the exit code
+func runBench(userConfigFile string, args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	runs := fs.Int("n", 100, "number of times to render the template")
+	dir := fs.String("dir", "", "directory to render the template in (the current one by default)")
+	file := fs.String("file", "", "template file to render")
+	baselineFile := fs.String("baseline", "", "report to compare with")
+	saveFile := fs.String("save", "", "file to save the report to, as json")
+	threshold := fs.Float64("threshold", 20, "allowed p95 latency increase from the baseline, in percent")
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, benchUsage) }
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if *runs < 1 {
+		fmt.Fprintln(os.Stderr, "-n must be at least 1")
+		return 1
+	}
+	if *dir != "" {
+		if err := chdir(*dir); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to change to %s: %v\n", *dir, err)
+			return 1
+		}
+	}
+
+	templatesDir := os.Getenv("HOME") + "/.config/goprompt/templates"
+	templates, err := prompt.LoadTemplates(templatesDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	name, tmpl, err := templateToCheck(userConfigFile, *file, fs.Args(), templates, templatesDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	config, err := loadConfig(userConfigFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	layers, _ := optionLayers(config, name, true)
+
+	var baseline *prompt.BenchReport
+	if *baselineFile != "" {
+		baseline = &prompt.BenchReport{}
+		if err := readJSON(*baselineFile, baseline); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to read baseline: %v\n", err)
+			return 1
+		}
+	}
+
+	//the theme and icons do not change the latency much, the defaults are used
+	pr := prompt.New(prompt.MergeOptions(layers...), theme.Default(), icon.Set{}, theme.ANSI, false)
+	pr.AddTemplates(templates)
+	for _, err := range pr.AddSegments(config.GetSegments()) {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	//the configured plugins only, the ones on PATH are looked up when used
+	pr.AddExternalPlugins(prompt.FindExternalPlugins("", config.GetPlugins()))
+	pr.LookupExternalPlugins()
+	report := pr.Bench(tmpl, *runs)
+	fmt.Printf("%s: ", name)
+	report.Write(os.Stdout, baseline)
+
+	if *saveFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(*saveFile, data, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to save report: %v\n", err)
+			return 1
+		}
+	}
+	if baseline != nil {
+		regressions := report.Compare(*baseline, *threshold)
+		for _, r := range regressions {
+			fmt.Fprintln(os.Stderr, r.Error())
+		}
+		if len(regressions) > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+//readJSON reads the json file into v
+func readJSON(file string, v interface{}) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
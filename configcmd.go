@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/josledp/goprompt/prompt"
+)
+
+const configUsage = `usage: goprompt config <command> [-file config] [args]
+
+Commands:
+  get <key>            shows the value of key in the config file
+  set <key> <value>    sets key in the config file (plugin options are checked against their type)
+  unset <key>          removes key from the config file
+  show [-effective]    shows the merged configuration, or every option in effect and where it comes from
+  edit                 opens the config file with $EDITOR and validates it afterwards
+  validate             validates all the configuration files
+
+Keys are template, custom_template, template_file, theme, icons, trusted_dirs (comma separated) or any plugin option (e.g. path.fullpath).
+By default get, set, unset and edit work on the user config file`
+
+//runConfig runs the config subcommands and returns the exit code
+func runConfig(userConfigFile string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 1
+	}
+	cmd := args[0]
+	fs := flag.NewFlagSet("config "+cmd, flag.ContinueOnError)
+	file := fs.String("file", userConfigFile, "config file to work with")
+	effective := fs.Bool("effective", false, "show every option in effect with its source")
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, configUsage) }
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+	args = fs.Args()
+
+	var err error
+	switch cmd {
+	case "get":
+		err = configGet(*file, args)
+	case "set":
+		err = configSet(*file, args)
+	case "unset":
+		err = configUnset(*file, args)
+	case "show":
+		err = configShow(userConfigFile, *effective)
+	case "edit":
+		err = configEdit(*file)
+	case "validate":
+		err = configValidate(userConfigFile)
+	default:
+		fmt.Fprintln(os.Stderr, configUsage)
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func configGet(file string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: goprompt config get <key>")
+	}
+	cf, err := prompt.OpenConfigFile(file)
+	if err != nil {
+		return err
+	}
+	v, ok := cf.Get(args[0])
+	if !ok {
+		return fmt.Errorf("%s is not set in %s", args[0], cf.Path())
+	}
+	fmt.Println(formatValue(v))
+	return nil
+}
+
+func configSet(file string, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: goprompt config set <key> <value>")
+	}
+	cf, err := prompt.OpenConfigFile(file)
+	if err != nil {
+		return err
+	}
+	if err = cf.Set(args[0], args[1]); err != nil {
+		return err
+	}
+	return cf.Save()
+}
+
+func configUnset(file string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: goprompt config unset <key>")
+	}
+	cf, err := prompt.OpenConfigFile(file)
+	if err != nil {
+		return err
+	}
+	if !cf.Unset(args[0]) {
+		return fmt.Errorf("%s is not set in %s", args[0], cf.Path())
+	}
+	return cf.Save()
+}
+
+func configShow(userConfigFile string, effective bool) error {
+	config, err := loadConfig(userConfigFile)
+	if err != nil {
+		return err
+	}
+	template, _ := config.GetTemplate()
+	customTemplate, _ := config.GetCustomTemplate()
+	templateFile, _ := config.GetTemplateFile()
+
+	if !effective {
+		fmt.Printf("files: %s\n", strings.Join(config.GetSources(), ", "))
+		if untrusted, ok := config.GetUntrusted(); ok {
+			fmt.Printf("untrusted (ignored): %s\n", untrusted)
+		}
+		fmt.Printf("template: %s\n", template)
+		fmt.Printf("custom_template: %s\n", customTemplate)
+		fmt.Printf("template_file: %s\n", templateFile)
+		options, _ := config.GetOptions()
+		fmt.Printf("options:\n")
+		for _, k := range sortedKeys(options) {
+			fmt.Printf("  %s: %s\n", k, formatValue(options[k]))
+		}
+		return nil
+	}
+
+	if template == "" {
+		template = prompt.DefaultTemplate
+	}
+	layers, errs := optionLayers(config, template, customTemplate == "" && templateFile == "")
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	options := prompt.EffectiveOptions(layers...)
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s = %s (%s)\n", name, formatValue(options[name].Value), options[name].Source)
+	}
+	return nil
+}
+
+func configEdit(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		if _, err = prompt.NewConfigFromFile(file); err != nil {
+			return err
+		}
+	}
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", file)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running editor %s: %v", editor, err)
+	}
+	return validate(prompt.NewConfigFromFile(file))
+}
+
+func configValidate(userConfigFile string) error {
+	return validate(loadConfig(userConfigFile))
+}
+
+func validate(config *prompt.Config, err error) error {
+	if err != nil {
+		return err
+	}
+	errs := config.Validate()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("found %d errors", len(errs))
+	}
+	return nil
+}
+
+func loadConfig(userConfigFile string) (*prompt.Config, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("unable to get current directory: %v", err)
+	}
+	return prompt.NewLayeredConfig(userConfigFile, cwd)
+}
+
+//formatValue formats strings as they are and any other value as json
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,9 +1,9 @@
 module github.com/josledp/goprompt
 
-go 1.12
+go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/jeffwelling/git2go/v37 v37.0.4
-	github.com/josledp/termcolor v0.0.0-20170922073712-15084bd81a38
 	gopkg.in/yaml.v2 v2.2.2
 )
@@ -5,96 +5,381 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime/pprof"
+	"runtime/trace"
 	"strings"
+	"time"
 
 	"github.com/josledp/goprompt/prompt"
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 var logger *log.Logger
 
-//enableCPUProf call me this way if you want CPU Profiling:
-// defer enableCPUProf()()
-func enableCPUProf() func() {
+//optionFlags collects the repeatable -option key=value flags
+type optionFlags []string
 
-	f, err := os.Create("/tmp/cpu.prof")
-	if err != nil {
-		log.Fatal(err)
-	}
-	pprof.StartCPUProfile(f)
-	return pprof.StopCPUProfile
+func (o *optionFlags) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *optionFlags) Set(value string) error {
+	*o = append(*o, value)
+	return nil
 }
 
 func main() {
 	var noColor bool
 	var template string
 	var customTemplate string
+	var templateFile string
+	var themeName string
+	var iconsName string
+	var format string
+	var cwdFlag string
+	var cacheTTL time.Duration
 	var helpPlugin, helpTemplate bool
+	var checkConfig bool
+	var cliOptions optionFlags
 	var debug bool
+	var explain, timings bool
+	var cpuProfile, traceFile string
 
-	config, err := prompt.NewConfigFromFile(os.Getenv("HOME") + "/.config/goprompt/goprompt.json")
+	userConfigFile := prompt.FindConfigFile(os.Getenv("HOME") + "/.config/goprompt/goprompt.json")
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(userConfigFile, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "template" {
+		os.Exit(runTemplate(userConfigFile, os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(userConfigFile, os.Args[2:]))
+	}
+	args := os.Args[1:]
+	//goprompt explain renders the prompt like goprompt does, reporting what every plugin did
+	if len(args) > 0 && args[0] == "explain" {
+		explain = true
+		args = args[1:]
+	}
+
+	//-cwd is applied before loading the config, so the project config of that directory is used
+	if dir := argValue(args, "cwd"); dir != "" {
+		if err := chdir(dir); err != nil {
+			log.Fatalf("unable to change to %s: %v", dir, err)
+		}
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		log.Fatalf("unable to get current directory: %v", err)
+	}
+	config, err := prompt.NewLayeredConfig(userConfigFile, cwd)
 	if err != nil {
 		log.Fatalf("unable to get config: %v", err)
 	}
 
 	defaultTemplate, ok := config.GetTemplate()
 	if !ok {
-		defaultTemplate = "Evermeet"
+		defaultTemplate = prompt.DefaultTemplate
+	}
+	defaultTheme, ok := config.GetTheme()
+	if !ok {
+		defaultTheme = theme.DefaultName
+	}
+	//the linux console lacks the glyphs of any other set, even a configured one. Only -icons
+	//overrides it
+	defaultIcons, ok := config.GetIcons()
+	if detected := icon.Detect(os.Getenv); !ok || detected == icon.ASCII {
+		defaultIcons = detected
 	}
 	currentTemplates := strings.Join(prompt.GetDefaultTemplates(), ",")
-	flag.StringVar(&template, "template", defaultTemplate, "template to use for the prompt ("+currentTemplates+")")
+	flag.StringVar(&template, "template", defaultTemplate, "template to use for the prompt ("+currentTemplates+", or a template file name in ~/.config/goprompt/templates)")
+	flag.StringVar(&templateFile, "template-file", "", "template file to use for the prompt")
 	flag.StringVar(&customTemplate, "custom-template", "<(%python%) ><%aws%|><%user% ><%lastcommand% ><%path%>< %git%>$ ", "template to use for the prompt")
+	flag.StringVar(&themeName, "theme", defaultTheme, "theme to use for the prompt ("+strings.Join(theme.Names(), ",")+", or a theme file name in ~/.config/goprompt/themes)")
+	flag.StringVar(&iconsName, "icons", defaultIcons, "icon set to use for the prompt ("+strings.Join(icon.Names(), ",")+"), ascii by default on the linux console")
 	flag.BoolVar(&debug, "debug", false, "Enable debug")
-	flag.BoolVar(&noColor, "no-color", false, "Disable color on prompt")
+	flag.BoolVar(&noColor, "no-color", os.Getenv("NO_COLOR") != "", "Disable color on prompt (disabled by default if NO_COLOR is set)")
+	flag.StringVar(&format, "format", "shell", "output format: shell (the prompt), tmux (tmux status line markup) or json (the prompt and the text and data of every plugin)")
+	flag.StringVar(&cwdFlag, "cwd", "", "directory to show the prompt for instead of the current one (e.g. tmux #{pane_current_path})")
+	flag.DurationVar(&cacheTTL, "cache-ttl", 0, "reuse the output for this long when run again with the same arguments, directory and environment (5s by default with -format tmux)")
 	flag.BoolVar(&helpPlugin, "help-plugin", false, "Shows plugins help")
 	flag.BoolVar(&helpTemplate, "help-template", false, "Shows templating help")
+	flag.BoolVar(&checkConfig, "check-config", false, "Validates the configuration files and exits")
+	flag.BoolVar(&timings, "timings", false, "Prints on stderr the time spent by every plugin, as goprompt explain does")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "writes a CPU profile to this file")
+	flag.StringVar(&traceFile, "trace", "", "writes an execution trace to this file")
+	flag.Var(&cliOptions, "option", "Sets a plugin option as key=value (can be repeated). Precedence: command line > "+prompt.EnvOptionPrefix+"<KEY> environment variables > config files > template defaults")
 
-	flag.Parse()
+	flag.CommandLine.Parse(args)
+
+	stopProfiling, err := startProfiling(cpuProfile, traceFile)
+	if err != nil {
+		log.Fatalf("unable to start profiling: %v", err)
+	}
+	defer stopProfiling()
+	//os.Exit skips the deferred calls, the profiles are completed before
+	exit := func(code int) {
+		stopProfiling()
+		os.Exit(code)
+	}
+
+	if debug {
+		fmt.Fprintf(os.Stderr, "config files: %s\n", strings.Join(config.GetSources(), ","))
+		if untrusted, ok := config.GetUntrusted(); ok {
+			fmt.Fprintf(os.Stderr, "ignoring untrusted project config %s\n", untrusted)
+		}
+	}
+	if checkConfig || debug {
+		errs := config.Validate()
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if checkConfig {
+			if len(errs) > 0 {
+				exit(1)
+			}
+			exit(0)
+		}
+	}
 
 	if helpPlugin {
-		prompt.ShowHelpPlugin(os.Stdout)
-		os.Exit(0)
+		prompt.ShowHelpPlugin(os.Stdout, config)
+		exit(0)
 	}
 	if helpTemplate {
 		prompt.ShowHelpTemplate(os.Stdout)
-		os.Exit(0)
+		exit(0)
 	}
 
 	flagsSet := make(map[string]struct{})
 	flag.Visit(func(f *flag.Flag) { flagsSet[f.Name] = struct{}{} })
 
+	if _, ok := flagsSet["cache-ttl"]; !ok && format == "tmux" {
+		cacheTTL = 5 * time.Second
+	}
+	key := outputKey(cwd, args, os.Environ())
+	if explain || timings {
+		cacheTTL = 0
+	}
+	if cacheTTL > 0 {
+		if output, ok := cachedOutput(key, cacheTTL); ok {
+			fmt.Println(output)
+			return
+		}
+	}
+
 	_, templateSet := flagsSet["template"]
 	_, customTemplateSet := flagsSet["custom-template"]
+	_, templateFileSet := flagsSet["template-file"]
 
-	if templateSet && customTemplateSet {
-		fmt.Fprintf(os.Stderr, "please provice -template or -custom-template, but not both!")
-		os.Exit(1)
+	if (templateSet && customTemplateSet) || (templateFileSet && (templateSet || customTemplateSet)) {
+		fmt.Fprintf(os.Stderr, "please provice only one of -template, -custom-template or -template-file!")
+		exit(1)
+	}
+
+	templatesDir := os.Getenv("HOME") + "/.config/goprompt/templates"
+	templates, err := prompt.LoadTemplates(templatesDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
 	}
 
 	var t string
-	var options map[string]interface{}
-	options, _ = config.GetOptions()
+	var fromTemplate bool
 
-	//If we provide a customTemplate in the command line use it. Otherwise, if template parameter is not set try to load the template from the config
+	//If we provide a customTemplate or a template file in the command line use it. Otherwise, if template parameter is not set try to load the template from the config
 	if customTemplateSet {
 		t = customTemplate
+	} else if templateFileSet {
+		t = readTemplateFile(templateFile, templatesDir)
 	} else if !templateSet {
 		t, _ = config.GetCustomTemplate()
+		if file, ok := config.GetTemplateFile(); ok && t == "" {
+			t = readTemplateFile(file, templatesDir)
+		}
 	}
 
 	//If we have not a template yet, get it (from the template parameter, or from the template option in the config)
 	if t == "" {
-		var ok bool
-		t, ok = prompt.GetTemplate(template)
-		if !ok {
-			fmt.Fprintf(os.Stderr, "template %s not found", template)
+		template, t, err = prompt.FindTemplate(templates, template)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		fromTemplate = true
+	}
+
+	layers, errs := optionLayers(config, template, fromTemplate)
+	if debug {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	cliLayer := prompt.OptionLayer{Source: "command line", Options: make(map[string]interface{})}
+	for _, o := range cliOptions {
+		key, value, err := prompt.ParseOption(o)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			exit(1)
+		}
+		cliLayer.Options[key] = value
+	}
+	layers = append(layers, cliLayer)
+
+	th, err := theme.Load(themeName, os.Getenv("HOME")+"/.config/goprompt/themes")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load theme: %v\n", err)
+		th = theme.Default()
+	}
+
+	icons, err := icon.Load(iconsName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load icons: %v\n", err)
+	}
+
+	var target theme.Target
+	switch format {
+	case "shell":
+		target = prompt.DetectTarget(!noColor)
+	case "tmux":
+		target = theme.Tmux
+		if noColor {
+			target = theme.Plain
 		}
-		if options == nil {
-			options, _ = prompt.GetTemplateOptions(template)
+	case "json":
+		target = theme.Plain
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %s\n", format)
+		exit(1)
+	}
+	pr := prompt.New(prompt.MergeOptions(layers...), th, icons, target, debug)
+	pr.AddTemplates(templates)
+	if errs := pr.AddSegments(config.GetSegments()); debug {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+	//the configured plugins only, the ones on PATH are looked up when used
+	pr.AddExternalPlugins(prompt.FindExternalPlugins("", config.GetPlugins()))
+	pr.LookupExternalPlugins()
+
+	var output string
+	if format == "json" {
+		output = pr.CompileJSON(t)
+	} else {
+		output = pr.Compile(t)
+	}
+	if explain {
+		pr.Explain().Write(os.Stdout)
+		return
+	}
+	if timings {
+		pr.Explain().Write(os.Stderr)
+	}
+	if cacheTTL > 0 {
+		if err := saveOutput(key, output, cacheTTL); err != nil && debug {
+			fmt.Fprintf(os.Stderr, "unable to cache output: %v\n", err)
 		}
 	}
-	pr := prompt.New(options, !noColor, debug)
-	output := pr.Compile(t)
 	fmt.Println(output)
 
 }
+
+//startProfiling starts writing a CPU profile and an execution trace to the given files,
+//if not empty. The returned function stops them
+func startProfiling(cpuProfile, traceFile string) (func(), error) {
+	var stops []func()
+	stop := func() {
+		for _, s := range stops {
+			s()
+		}
+	}
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return stop, err
+		}
+		if err = pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return stop, err
+		}
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			f.Close()
+		})
+	}
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			stop()
+			return func() {}, err
+		}
+		if err = trace.Start(f); err != nil {
+			f.Close()
+			stop()
+			return func() {}, err
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			f.Close()
+		})
+	}
+	return stop, nil
+}
+
+//optionLayers returns the option layers, from lowest to highest precedence, except the command line:
+//the template defaults (if a predefined template is used), the config files and the environment
+func optionLayers(config *prompt.Config, template string, fromTemplate bool) ([]prompt.OptionLayer, []error) {
+	var layers []prompt.OptionLayer
+	if fromTemplate {
+		if templateOptions, ok := prompt.GetTemplateOptions(template); ok {
+			layers = append(layers, prompt.OptionLayer{Source: "template " + template, Options: templateOptions})
+		}
+	}
+	layers = append(layers, config.GetOptionLayers()...)
+	envLayer, errs := prompt.EnvOptions()
+	layers = append(layers, envLayer)
+	return layers, errs
+}
+
+//argValue returns the value of the flag name in args (-name value, -name=value or with --),
+//for the flags needed before the command line is parsed
+func argValue(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		a := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if a == arg {
+			continue
+		}
+		if a == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(a, name+"=") {
+			return a[len(name)+1:]
+		}
+	}
+	return ""
+}
+
+//chdir changes the working directory to dir, updating PWD as the plugins read it from there
+func chdir(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	if err = os.Chdir(dir); err != nil {
+		return err
+	}
+	return os.Setenv("PWD", dir)
+}
+
+//readTemplateFile reads a template file (relative to the templates dir), reporting the errors on stderr
+func readTemplateFile(file, templatesDir string) string {
+	t, err := prompt.ReadTemplateFile(prompt.TemplateFilePath(file, templatesDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	return t
+}
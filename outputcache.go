@@ -0,0 +1,89 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//outputCacheDir returns the directory where the outputs are cached, in the user cache
+//directory so other users can not plant outputs
+func outputCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to find the user cache dir: %v", err)
+	}
+	return filepath.Join(dir, "goprompt", "output"), nil
+}
+
+//outputKey returns the cache key of an output: it depends on the directory, the arguments
+//and the environment, as the plugins read variables like LAST_COMMAND_RC or VIRTUAL_ENV
+func outputKey(cwd string, args, env []string) string {
+	env = append([]string(nil), env...)
+	sort.Strings(env)
+	h := sha1.Sum([]byte(cwd + "\x00" + strings.Join(args, "\x00") + "\x00\x00" + strings.Join(env, "\x00")))
+	return hex.EncodeToString(h[:])
+}
+
+//cachedOutput returns the output cached for key if it is not older than ttl
+func cachedOutput(key string, ttl time.Duration) (string, bool) {
+	dir, err := outputCacheDir()
+	if err != nil {
+		return "", false
+	}
+	file := filepath.Join(dir, key)
+	fi, err := os.Stat(file)
+	if err != nil || time.Since(fi.ModTime()) > ttl {
+		return "", false
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+//saveOutput caches output for key, removing the outputs older than ttl: every directory
+//and environment gets its own file
+func saveOutput(key, output string, ttl time.Duration) error {
+	dir, err := outputCacheDir()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("unable to create cache dir %s: %v", dir, err)
+	}
+	pruneOutputs(dir, ttl)
+	tmp, err := ioutil.TempFile(dir, "."+key)
+	if err != nil {
+		return fmt.Errorf("unable to create cache file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.WriteString(output); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write cache file: %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write cache file: %v", err)
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, key))
+}
+
+//pruneOutputs removes the outputs in dir older than ttl
+func pruneOutputs(dir string, ttl time.Duration) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if !f.IsDir() && time.Since(f.ModTime()) > ttl {
+			os.Remove(filepath.Join(dir, f.Name()))
+		}
+	}
+}
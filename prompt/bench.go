@@ -0,0 +1,146 @@
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+//Latency are the percentiles of the time spent rendering the prompt or loading a plugin
+type Latency struct {
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
+}
+
+//BenchReport is the latency of rendering a template several times, overall and by plugin
+type BenchReport struct {
+	Runs    int                `json:"runs"`
+	Total   Latency            `json:"total"`
+	Plugins map[string]Latency `json:"plugins"`
+}
+
+//Regression is a latency (p95) higher than the baseline one by more than the allowed threshold
+type Regression struct {
+	Name     string
+	Baseline time.Duration
+	Current  time.Duration
+}
+
+func (r Regression) Error() string {
+	return fmt.Sprintf("%s: p95 %v, baseline %v (%+.0f%%)", r.Name, r.Current, r.Baseline, change(r.Baseline, r.Current))
+}
+
+//Bench renders the template runs times, as separate goprompt runs would (with freshly
+//loaded plugins each time), and returns the latency percentiles. Every run starts with an
+//empty in memory cache, so the exec and external plugins run their commands and nothing is
+//written to disk. The git plugin does not fetch, as the background fetch would run in the
+//middle of the measures
+func (pr *Prompt) Bench(tmpl string, runs int) BenchReport {
+	if pr.options == nil {
+		pr.options = make(map[string]interface{})
+	}
+	if fetch, ok := pr.options["git.fetch_interval"]; ok {
+		defer func() { pr.options["git.fetch_interval"] = fetch }()
+	} else {
+		defer delete(pr.options, "git.fetch_interval")
+	}
+	pr.options["git.fetch_interval"] = time.Duration(0)
+	defer func(c *Cache) { pr.cache = c }(pr.cache)
+
+	var total []time.Duration
+	plugins := make(map[string][]time.Duration)
+	for i := 0; i < runs; i++ {
+		pr.cache, _ = NewCache("")
+		pr.Compile(tmpl)
+		e := pr.Explain()
+		total = append(total, e.Duration)
+		for _, p := range e.Plugins {
+			plugins[p.Name] = append(plugins[p.Name], p.Duration)
+		}
+	}
+	r := BenchReport{Runs: runs, Total: percentiles(total), Plugins: make(map[string]Latency, len(plugins))}
+	for name, d := range plugins {
+		r.Plugins[name] = percentiles(d)
+	}
+	return r
+}
+
+//percentiles returns the nearest-rank percentiles of d
+func percentiles(d []time.Duration) Latency {
+	if len(d) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), d...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(p int) time.Duration {
+		i := (p*len(sorted)+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i]
+	}
+	return Latency{P50: rank(50), P95: rank(95), P99: rank(99)}
+}
+
+//Compare returns the overall and plugin latencies (p95) more than threshold percent higher than in baseline
+func (r BenchReport) Compare(baseline BenchReport, threshold float64) []Regression {
+	var regressions []Regression
+	check := func(name string, current, base Latency) {
+		if base.P95 > 0 && change(base.P95, current.P95) > threshold {
+			regressions = append(regressions, Regression{Name: name, Baseline: base.P95, Current: current.P95})
+		}
+	}
+	check("total", r.Total, baseline.Total)
+	for _, name := range r.pluginNames() {
+		if base, ok := baseline.Plugins[name]; ok {
+			check(name, r.Plugins[name], base)
+		}
+	}
+	return regressions
+}
+
+//Write writes the report as a table, with the p95 change from baseline if not nil
+func (r BenchReport) Write(w io.Writer, baseline *BenchReport) {
+	fmt.Fprintf(w, "%d runs\n", r.Runs)
+	fmt.Fprintf(w, "%-14s %12s %12s %12s", "", "p50", "p95", "p99")
+	if baseline != nil {
+		fmt.Fprintf(w, " %12s %8s", "baseline p95", "change")
+	}
+	fmt.Fprintln(w)
+	row := func(name string, l Latency, base Latency, ok bool) {
+		fmt.Fprintf(w, "%-14s %12v %12v %12v", name, l.P50, l.P95, l.P99)
+		if ok {
+			fmt.Fprintf(w, " %12v %+7.0f%%", base.P95, change(base.P95, l.P95))
+		}
+		fmt.Fprintln(w)
+	}
+	if baseline == nil {
+		baseline = &BenchReport{}
+		row("total", r.Total, Latency{}, false)
+	} else {
+		row("total", r.Total, baseline.Total, true)
+	}
+	for _, name := range r.pluginNames() {
+		base, ok := baseline.Plugins[name]
+		row(name, r.Plugins[name], base, ok)
+	}
+}
+
+func (r BenchReport) pluginNames() []string {
+	names := make([]string, 0, len(r.Plugins))
+	for name := range r.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//change returns the change from base to current, in percent
+func change(base, current time.Duration) float64 {
+	if base == 0 {
+		return 0
+	}
+	return float64(current-base) * 100 / float64(base)
+}
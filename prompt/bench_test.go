@@ -0,0 +1,122 @@
+package prompt
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/internal/fixture"
+	"github.com/josledp/goprompt/prompt/plugin"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestPercentiles(t *testing.T) {
+	var d []time.Duration
+	for i := 100; i > 0; i-- {
+		d = append(d, time.Duration(i)*time.Millisecond)
+	}
+	expected := Latency{P50: 50 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond}
+	if l := percentiles(d); l != expected {
+		t.Errorf("Expected %+v, got %+v", expected, l)
+	}
+	if l := percentiles([]time.Duration{time.Second}); l != (Latency{time.Second, time.Second, time.Second}) {
+		t.Errorf("unexpected single run percentiles %+v", l)
+	}
+}
+
+func TestBenchCompare(t *testing.T) {
+	baseline := BenchReport{
+		Runs:  10,
+		Total: Latency{P95: 10 * time.Millisecond},
+		Plugins: map[string]Latency{
+			"git":  {P95: 8 * time.Millisecond},
+			"path": {P95: time.Millisecond},
+		},
+	}
+	current := BenchReport{
+		Runs:  10,
+		Total: Latency{P95: 11 * time.Millisecond},
+		Plugins: map[string]Latency{
+			"git":  {P95: 12 * time.Millisecond},
+			"path": {P95: time.Millisecond},
+			"aws":  {P95: time.Second},
+		},
+	}
+	expected := []Regression{{Name: "git", Baseline: 8 * time.Millisecond, Current: 12 * time.Millisecond}}
+	if r := current.Compare(baseline, 20); !reflect.DeepEqual(r, expected) {
+		t.Errorf("Expected %v, got %v", expected, r)
+	}
+	if r := current.Compare(baseline, 5); len(r) != 2 || r[0].Name != "total" {
+		t.Errorf("expected total and git regressions, got %v", r)
+	}
+
+	b := &bytes.Buffer{}
+	current.Write(b, &baseline)
+	if !strings.Contains(b.String(), "+50%") {
+		t.Errorf("expected git change in report:\n%s", b.String())
+	}
+}
+
+//cachingPlugin reuses the value it cached on its first load
+type cachingPlugin struct {
+	fakePlugin
+}
+
+func (c cachingPlugin) Load(pr plugin.Prompter) error {
+	if _, ok := pr.GetCache(c.name); ok {
+		pr.(plugin.CacheReporter).CacheHit()
+		return nil
+	}
+	return pr.Cache(c.name, true)
+}
+
+func TestBench(t *testing.T) {
+	c := &Cache{}
+	pr := Prompt{
+		cache: c,
+		plugins: map[string]Plugin{
+			"a": fakePlugin{name: "a", output: "A", role: "a"},
+			"b": fakePlugin{name: "b", output: "B", role: "b"},
+			"c": cachingPlugin{fakePlugin{name: "c", output: "C", role: "c"}},
+		},
+		format: theme.Default().Formatter(theme.Renderer{Target: theme.Plain}),
+	}
+	r := pr.Bench(`{{load "a"}}{{load "b"}}{{load "c"}}$ `, 5)
+	if r.Runs != 5 || len(r.Plugins) != 3 {
+		t.Errorf("unexpected report %+v", r)
+	}
+	//every run starts with an empty cache, the prompt one is left untouched
+	for _, p := range pr.Explain().Plugins {
+		if p.Cached {
+			t.Errorf("expected %s not to use the cache", p.Name)
+		}
+	}
+	if pr.cache != c || len(c.data) != 0 {
+		t.Errorf("expected the prompt cache to be restored unchanged, got %v", pr.cache.data)
+	}
+}
+
+func BenchmarkTemplates(b *testing.B) {
+	if os.Getenv("USER") == "" {
+		os.Setenv("USER", "goprompt")
+	}
+	repos := map[string]fixture.Repo{"small": fixture.Small, "large": fixture.Large}
+	for _, repo := range []string{"small", "large"} {
+		dir := fixture.Generate(b, repos[repo])
+		for _, name := range GetDefaultTemplates() {
+			tmpl, _ := GetTemplate(name)
+			options, _ := GetTemplateOptions(name)
+			b.Run(repo+"/"+name, func(b *testing.B) {
+				fixture.Chdir(b, dir)
+				pr := New(options, nil, icon.Set{}, theme.ANSI, false)
+				for i := 0; i < b.N; i++ {
+					pr.Compile(tmpl)
+				}
+			})
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"time"
 )
 
 //Cache represents a cache data store
@@ -12,30 +13,41 @@ type Cache struct {
 	file     string
 	data     map[string]interface{}
 	modified bool
+	hits     int
 }
 
 func newCache() (*Cache, error) {
-	var err error
-	c := Cache{}
 	user := os.Getenv("USER")
 	if user == "" {
 		return nil, fmt.Errorf("Unable to get current user")
 	}
-	c.file = fmt.Sprintf("/var/tmp/goprompt-%s", user)
+	return NewCache(fmt.Sprintf("/var/tmp/goprompt-%s", user))
+}
 
+//NewCache returns a cache stored in file, loading it if it exists. If file is empty the
+//cache is kept in memory only
+func NewCache(file string) (*Cache, error) {
+	var err error
+	c := Cache{file: file}
+	if file == "" {
+		return &c, nil
+	}
 	if _, oserr := os.Stat(c.file); !os.IsNotExist(oserr) {
 		err = c.load()
 	}
 	return &c, err
 }
 
-func (c *Cache) save() error {
+//save writes the cache to its file if it was modified, leaving out the values expired at now
+func (c *Cache) save(now time.Time) error {
+	c.prune(now)
 	if !c.modified {
 		return nil
 	}
 
+	//memory only cache
 	if c.file == "" {
-		return fmt.Errorf("Cache not initialized")
+		return nil
 	}
 
 	b, err := json.Marshal(c.data)
@@ -50,6 +62,21 @@ func (c *Cache) save() error {
 
 }
 
+//prune removes the values cached with an expiration by the plugins (see plugin.cacheValue)
+//expired at now, so the keys depending on the directory or the environment do not pile up
+func (c *Cache) prune(now time.Time) {
+	for key, v := range c.data {
+		cached, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if expires, ok := cached["expires"].(float64); ok && now.Unix() >= int64(expires) {
+			delete(c.data, key)
+			c.modified = true
+		}
+	}
+}
+
 func (c *Cache) load() error {
 	d, err := ioutil.ReadFile(c.file)
 	if err != nil {
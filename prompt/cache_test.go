@@ -3,6 +3,7 @@ package prompt
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestCache(t *testing.T) {
@@ -16,7 +17,7 @@ func TestCache(t *testing.T) {
 		"data2": "string",
 		"data3": false,
 	}
-	err = c.save()
+	err = c.save(time.Now())
 	if err != nil {
 		t.Fatalf("unable to save cache: %v", err)
 	}
@@ -29,3 +30,18 @@ func TestCache(t *testing.T) {
 		}
 	}
 }
+
+func TestCachePrune(t *testing.T) {
+	c, _ := NewCache("")
+	c.data = map[string]interface{}{
+		"expired": map[string]interface{}{"expires": float64(100), "value": "x"},
+		"valid":   map[string]interface{}{"expires": float64(300), "value": "y"},
+		"plain":   "z",
+	}
+	if err := c.save(time.Unix(200, 0)); err != nil {
+		t.Fatalf("unable to save cache: %v", err)
+	}
+	if _, ok := c.data["expired"]; ok || len(c.data) != 2 {
+		t.Errorf("expected only the expired value to be removed, got %v", c.data)
+	}
+}
@@ -0,0 +1,200 @@
+package prompt
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+//TemplateError is a problem found checking a template
+type TemplateError struct {
+	Location string
+	Msg      string
+}
+
+func (e TemplateError) Error() string {
+	if e.Location == "" {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s: %s", e.Location, e.Msg)
+}
+
+//parseErrorRe splits the location from the go template errors
+var parseErrorRe = regexp.MustCompile(`^template: ([^:]+:\d+(?::\d+)?): (.*)$`)
+
+//pluginArgs are the template functions taking plugin names, by the position of their
+//first plugin argument. The later arguments are plugins too if variadic
+var pluginArgs = map[string]struct {
+	first    int
+	variadic bool
+}{
+	"load":           {1, false},
+	"has":            {1, false},
+	"powerline":      {1, true},
+	"powerlineStyle": {2, true},
+}
+
+//CheckTemplate parses tmpl, with the partials it may include, and checks that every
+//plugin it uses exists (built-in or in external, see FindExternalPlugins), every included
+//template is defined and every powerline style is known
+func CheckTemplate(tmpl string, partials map[string]string, external map[string]string) []TemplateError {
+	pr := &Prompt{}
+	t, broken, err := parseTemplate(tmpl, pr.getFuncMap(), partials)
+	if err != nil {
+		return []TemplateError{parseError(err)}
+	}
+
+	var errs []TemplateError
+	checked := make(map[string]bool)
+	var check func(t *template.Template)
+	check = func(t *template.Template) {
+		if checked[t.Name()] || t.Tree == nil {
+			return
+		}
+		checked[t.Name()] = true
+		walk(t.Tree.Root, func(n parse.Node) {
+			switch n := n.(type) {
+			case *parse.TemplateNode:
+				included := t.Lookup(n.Name)
+				if err, ok := broken[n.Name]; ok && included == nil {
+					errs = append(errs, parseError(err))
+					return
+				}
+				if included == nil {
+					errs = append(errs, templateError(t.Tree, n, fmt.Sprintf("template %q not defined", n.Name)))
+					return
+				}
+				check(included)
+			case *parse.CommandNode:
+				errs = append(errs, checkCommand(t.Tree, n, external)...)
+			}
+		})
+	}
+	check(t)
+	return errs
+}
+
+//parseError returns the TemplateError of a template parse error
+func parseError(err error) TemplateError {
+	if m := parseErrorRe.FindStringSubmatch(err.Error()); m != nil {
+		return TemplateError{Location: m[1], Msg: m[2]}
+	}
+	return TemplateError{Msg: err.Error()}
+}
+
+//checkCommand checks the plugin names and powerline styles given to a function call
+func checkCommand(tree *parse.Tree, cmd *parse.CommandNode, external map[string]string) []TemplateError {
+	if len(cmd.Args) == 0 {
+		return nil
+	}
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok {
+		return nil
+	}
+	args, ok := pluginArgs[ident.Ident]
+	if !ok {
+		return nil
+	}
+
+	var errs []TemplateError
+	if ident.Ident == "powerlineStyle" && len(cmd.Args) > 1 {
+		if s, ok := cmd.Args[1].(*parse.StringNode); ok {
+			if _, ok := powerlineSeparators[s.Text]; !ok {
+				errs = append(errs, templateError(tree, s, fmt.Sprintf("unknown powerline style %q, expected one of %s", s.Text, strings.Join(PowerlineStyles(), ", "))))
+			}
+		}
+	}
+	last := args.first + 1
+	if args.variadic {
+		last = len(cmd.Args)
+	}
+	for i := args.first; i < last && i < len(cmd.Args); i++ {
+		s, ok := cmd.Args[i].(*parse.StringNode)
+		if !ok {
+			continue
+		}
+		if !knownPlugin(s.Text, external) {
+			errs = append(errs, templateError(tree, s, fmt.Sprintf("unknown plugin %q, expected one of %s", s.Text, strings.Join(pluginNames(external), ", "))))
+		}
+	}
+	return errs
+}
+
+//walk calls f for every node under n
+func walk(n parse.Node, f func(parse.Node)) {
+	if n == nil {
+		return
+	}
+	f(n)
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			walk(c, f)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, f)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			walk(c, f)
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			walk(a, f)
+		}
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, f)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, f)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, f)
+	case *parse.TemplateNode:
+		walk(n.Pipe, f)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, f func(parse.Node)) {
+	walk(n.Pipe, f)
+	walk(n.List, f)
+	walk(n.ElseList, f)
+}
+
+//templateError returns a TemplateError located at node n
+func templateError(tree *parse.Tree, n parse.Node, msg string) TemplateError {
+	location, _ := tree.ErrorContext(n)
+	return TemplateError{Location: location, Msg: msg}
+}
+
+//knownPlugin returns true if there is a built-in or external plugin called name
+func knownPlugin(name string, external map[string]string) bool {
+	if _, ok := external[name]; ok {
+		return true
+	}
+	for _, r := range availablePlugins {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+//pluginNames returns the names of the available and external plugins
+func pluginNames(external map[string]string) []string {
+	names := make([]string, 0, len(availablePlugins)+len(external))
+	for _, r := range availablePlugins {
+		names = append(names, r.name)
+	}
+	for name := range external {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,56 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestCheckTemplate(t *testing.T) {
+	partials := map[string]string{
+		"good":   `{{load "git"}}`,
+		"bad":    `{{load "svn"}}`,
+		"broken": `{{load "git"`,
+	}
+	testCases := []struct {
+		name     string
+		tmpl     string
+		expected []string
+	}{
+		{name: "valid", tmpl: `{{load "weather"}}{{load "path"}}{{if has "git"}}{{template "good"}}{{end}}{{powerlineStyle "round" "k8s" "aws"}}$ `},
+		{name: "syntax", tmpl: "{{load \"path\"}}\n{{lod \"git\"}}", expected: []string{`prompt:2: function "lod" not defined`}},
+		{name: "unclosed", tmpl: `{{load "path"`, expected: []string{"prompt:1: unclosed action"}},
+		{name: "unknown plugin", tmpl: "x\n  {{load \"gti\"|prefix \" \"}}", expected: []string{`prompt:2:9: unknown plugin "gti"`}},
+		{name: "unknown plugin in if", tmpl: `{{if has "kube"}}{{end}}`, expected: []string{`prompt:1:9: unknown plugin "kube"`}},
+		{name: "powerline", tmpl: `{{powerline "path" "svn"}}{{powerlineStyle "wavy" "git"}}`, expected: []string{`prompt:1:19: unknown plugin "svn"`, `prompt:1:43: unknown powerline style "wavy"`}},
+		{name: "partial", tmpl: `{{template "bad"}}{{template "missing"}}`, expected: []string{`bad:1:7: unknown plugin "svn"`, `prompt:1:29: template "missing" not defined`}},
+		{name: "broken partial", tmpl: `{{template "broken"}}`, expected: []string{"broken:1: unclosed action"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := CheckTemplate(tc.tmpl, partials, map[string]string{"weather": "goprompt-plugin-weather"})
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("expected %d errors, got %v", len(tc.expected), errs)
+			}
+			for i, err := range errs {
+				if !strings.HasPrefix(err.Error(), tc.expected[i]) {
+					t.Errorf("expected error %s, got %s", tc.expected[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileFallback(t *testing.T) {
+	pr := Prompt{
+		cache:   &Cache{},
+		plugins: map[string]Plugin{},
+		format:  theme.Default().Formatter(theme.Renderer{Target: theme.Plain}),
+	}
+	for _, tmpl := range []string{`{{load "path"`, `{{load "missing"}}`} {
+		if output := pr.Compile(tmpl); output != "[template error] $ " {
+			t.Errorf("%s: expected fallback prompt, got %q", tmpl, output)
+		}
+	}
+}
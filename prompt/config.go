@@ -7,17 +7,52 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	yaml "gopkg.in/yaml.v2"
 )
 
+//SystemConfigFile is the system wide configuration file
+const SystemConfigFile = "/etc/goprompt/goprompt.json"
+
+//projectConfigFiles are the per-project configuration files, looked up from the current directory upwards
+var projectConfigFiles = []string{".goprompt.json", ".goprompt.yaml", ".goprompt.yml", ".goprompt.toml"}
+
+//configFormats maps the supported config file extensions to their format
+var configFormats = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+	".toml": "toml",
+}
+
 //Config is the struct to fetch the config
 type Config struct {
 	params parameters
+	layers []configLayer
+
+	untrusted string
 }
 
 type parameters struct {
-	Template       string                 `json:"template"`
-	CustomTemplate string                 `json:"custom_template"`
-	Options        map[string]interface{} `json:"options"`
+	Template       string                   `json:"template" yaml:"template"`
+	CustomTemplate string                   `json:"custom_template" yaml:"custom_template"`
+	TemplateFile   string                   `json:"template_file,omitempty" yaml:"template_file"`
+	Options        map[string]interface{}   `json:"options" yaml:"options"`
+	Theme          string                   `json:"theme,omitempty" yaml:"theme"`
+	Icons          string                   `json:"icons,omitempty" yaml:"icons"`
+	TrustedDirs    []string                 `json:"trusted_dirs,omitempty" yaml:"trusted_dirs"`
+	Plugins        map[string]string        `json:"plugins,omitempty" yaml:"plugins"`
+	Segments       map[string]SegmentConfig `json:"segments,omitempty" yaml:"segments"`
+}
+
+//configLayer is one of the files merged into a Config
+type configLayer struct {
+	source string
+	data   []byte
+	params parameters
 }
 
 //NewConfigFromFile loads the config from a file and returns the config
@@ -41,13 +76,68 @@ func NewConfigFromFile(file string) (*Config, error) {
 			return nil, fmt.Errorf("unable to save initial configuration file: %v", err)
 		}
 	} else {
-		rw, err := os.Open(file)
-		if err != nil {
-			return nil, fmt.Errorf("error opening config file %s: %v", file, err)
+		return loadConfigFile(file)
+	}
+	return c, nil
+}
+
+//FindConfigFile returns file if it exists, otherwise the first existing file with the same name
+//and another supported format extension. If none exists it returns file
+func FindConfigFile(file string) string {
+	if _, err := os.Stat(file); err == nil {
+		return file
+	}
+	base := strings.TrimSuffix(file, filepath.Ext(file))
+	for _, ext := range []string{".json", ".yaml", ".yml", ".toml"} {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext
 		}
-		defer rw.Close()
-		return NewConfig(rw)
 	}
+	return file
+}
+
+//NewLayeredConfig loads the system, user and project configurations and merges them key by key.
+//The project configuration (.goprompt.json or .goprompt.yaml on cwd or any of its parents) is only
+//applied if its directory is inside one of the trusted_dirs declared by the system or user config
+func NewLayeredConfig(userFile, cwd string) (*Config, error) {
+	c := &Config{}
+
+	systemFile := FindConfigFile(SystemConfigFile)
+	system, err := loadConfigFile(systemFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		c.addLayer(systemFile, system.layers[0].data, system.params)
+	}
+
+	userFile = FindConfigFile(userFile)
+	user, err := NewConfigFromFile(userFile)
+	if err != nil {
+		return nil, err
+	}
+	var userData []byte
+	if len(user.layers) > 0 {
+		userData = user.layers[0].data
+	}
+	c.addLayer(userFile, userData, user.params)
+
+	project := findProjectConfig(cwd)
+	if project == "" {
+		return c, nil
+	}
+	if !c.trusted(filepath.Dir(project)) {
+		c.untrusted = project
+		return c, nil
+	}
+	pc, err := loadConfigFile(project)
+	if err != nil {
+		return nil, err
+	}
+	//A project config must not be able to extend the trust to other directories
+	pc.params.TrustedDirs = nil
+	c.addLayer(project, pc.layers[0].data, pc.params)
+
 	return c, nil
 }
 
@@ -80,13 +170,96 @@ func (c *Config) load(r io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("unable to read config: %v", err)
 	}
-	err = json.Unmarshal(data, &c.params)
-	if err != nil {
-		return fmt.Errorf("unable to unmarshal config: %v", err)
+	return c.loadFormat("json", data)
+}
+
+//loadFormat loads the config from data encoded as format (json, yaml or toml)
+func (c *Config) loadFormat(format string, data []byte) error {
+	if format == "json" {
+		err := json.Unmarshal(data, &c.params)
+		if err != nil {
+			if serr, ok := err.(*json.SyntaxError); ok {
+				return fmt.Errorf("unable to unmarshal config: line %d: %v", offsetLine(data, serr.Offset), err)
+			}
+			return fmt.Errorf("unable to unmarshal config: %v", err)
+		}
+	} else {
+		raw, err := unmarshalRaw(format, data)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal config: %v", err)
+		}
+		//Reencode it as json, so every format ends with the same value types
+		jdata, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("unable to convert config: %v", err)
+		}
+		err = json.Unmarshal(jdata, &c.params)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal config: %v", err)
+		}
 	}
+	c.params.Options = flattenOptions(c.params.Options)
 	return nil
 }
 
+//addLayer merges params over the current config. A layer choosing a template, a custom
+//template or a template file overrides the three settings from the previous layers
+func (c *Config) addLayer(source string, data []byte, params parameters) {
+	c.layers = append(c.layers, configLayer{source: source, data: data, params: params})
+
+	if params.Template != "" || params.CustomTemplate != "" || params.TemplateFile != "" {
+		c.params.Template = params.Template
+		c.params.CustomTemplate = params.CustomTemplate
+		c.params.TemplateFile = params.TemplateFile
+	}
+	if params.Theme != "" {
+		c.params.Theme = params.Theme
+	}
+	if params.Icons != "" {
+		c.params.Icons = params.Icons
+	}
+	if params.Options != nil {
+		if c.params.Options == nil {
+			c.params.Options = make(map[string]interface{})
+		}
+		for k, v := range params.Options {
+			c.params.Options[k] = v
+		}
+	}
+	c.params.TrustedDirs = append(c.params.TrustedDirs, params.TrustedDirs...)
+	if params.Plugins != nil {
+		if c.params.Plugins == nil {
+			c.params.Plugins = make(map[string]string)
+		}
+		for k, v := range params.Plugins {
+			c.params.Plugins[k] = v
+		}
+	}
+	if params.Segments != nil {
+		if c.params.Segments == nil {
+			c.params.Segments = make(map[string]SegmentConfig)
+		}
+		for k, v := range params.Segments {
+			c.params.Segments[k] = v
+		}
+	}
+}
+
+//trusted returns true if dir is inside any of the configured trusted directories
+func (c *Config) trusted(dir string) bool {
+	for _, t := range c.params.TrustedDirs {
+		t = expandHome(t)
+		if !filepath.IsAbs(t) {
+			continue
+		}
+		rel, err := filepath.Rel(filepath.Clean(t), dir)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
 //GetTemplate returns the configured predefined template
 func (c *Config) GetTemplate() (string, bool) {
 	return c.params.Template, c.params.Template != ""
@@ -97,7 +270,195 @@ func (c *Config) GetCustomTemplate() (string, bool) {
 	return c.params.CustomTemplate, c.params.CustomTemplate != ""
 }
 
+//GetPlugins returns the configured external plugins, their command by name
+func (c *Config) GetPlugins() map[string]string {
+	return c.params.Plugins
+}
+
+//GetSegments returns the segments declared in the config by name
+func (c *Config) GetSegments() map[string]SegmentConfig {
+	return c.params.Segments
+}
+
+//GetTemplateFile returns the configured template file
+func (c *Config) GetTemplateFile() (string, bool) {
+	return c.params.TemplateFile, c.params.TemplateFile != ""
+}
+
+//GetTheme returns the configured theme
+func (c *Config) GetTheme() (string, bool) {
+	return c.params.Theme, c.params.Theme != ""
+}
+
+//GetIcons returns the configured icon set
+func (c *Config) GetIcons() (string, bool) {
+	return c.params.Icons, c.params.Icons != ""
+}
+
 //GetOptions return the configured options
 func (c *Config) GetOptions() (map[string]interface{}, bool) {
 	return c.params.Options, c.params.Options != nil
 }
+
+//GetOptionLayers returns the options set by each config file, in the order they were merged
+func (c *Config) GetOptionLayers() []OptionLayer {
+	layers := make([]OptionLayer, 0, len(c.layers))
+	for _, l := range c.layers {
+		if l.params.Options != nil {
+			layers = append(layers, OptionLayer{Source: l.source, Options: l.params.Options})
+		}
+	}
+	return layers
+}
+
+//GetSources returns the files the config has been loaded from, in the order they were merged
+func (c *Config) GetSources() []string {
+	sources := make([]string, 0, len(c.layers))
+	for _, l := range c.layers {
+		sources = append(sources, l.source)
+	}
+	return sources
+}
+
+//GetUntrusted returns the project config file ignored because its directory is not trusted
+func (c *Config) GetUntrusted() (string, bool) {
+	return c.untrusted, c.untrusted != ""
+}
+
+//loadConfigFile loads a config file choosing the format by its extension
+func loadConfigFile(file string) (*Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	format, ok := configFormats[filepath.Ext(file)]
+	if !ok {
+		format = "json"
+	}
+
+	c := &Config{}
+	err = c.loadFormat(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("error loading config %s: %v", file, err)
+	}
+	c.layers = []configLayer{{source: file, data: data, params: c.params}}
+	return c, nil
+}
+
+//findProjectConfig walks up from dir looking for a project config file
+func findProjectConfig(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	dir = filepath.Clean(dir)
+	for {
+		for _, name := range projectConfigFiles {
+			file := filepath.Join(dir, name)
+			if fi, err := os.Stat(file); err == nil && !fi.IsDir() {
+				return file
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func expandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		if home := os.Getenv("HOME"); home != "" {
+			return home + p[1:]
+		}
+	}
+	return p
+}
+
+//unmarshalRaw decodes a yaml or toml document into a generic map
+func unmarshalRaw(format string, data []byte) (map[string]interface{}, error) {
+	var raw map[string]interface{}
+	switch format {
+	case "yaml":
+		var y map[interface{}]interface{}
+		if err := yaml.Unmarshal(data, &y); err != nil {
+			return nil, err
+		}
+		raw, _ = normalizeRaw(y).(map[string]interface{})
+	case "toml":
+		if _, err := toml.Decode(string(data), &raw); err != nil {
+			return nil, err
+		}
+		raw, _ = normalizeRaw(raw).(map[string]interface{})
+	case "json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown config format %s", format)
+	}
+	return raw, nil
+}
+
+//normalizeRaw converts the yaml/toml decoded values to the ones encoding/json would have produced
+func normalizeRaw(v interface{}) interface{} {
+	switch t := v.(type) {
+	case int:
+		return float64(t)
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprint(k)] = normalizeRaw(v)
+		}
+		return m
+	case map[string]interface{}:
+		for k, v := range t {
+			t[k] = normalizeRaw(v)
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = normalizeRaw(t[i])
+		}
+		return t
+	case []map[string]interface{}:
+		l := make([]interface{}, len(t))
+		for i := range t {
+			l[i] = normalizeRaw(t[i])
+		}
+		return l
+	}
+	return v
+}
+
+//flattenOptions converts nested options (path: {fullpath: 2}) into dotted keys (path.fullpath: 2)
+func flattenOptions(options map[string]interface{}) map[string]interface{} {
+	if options == nil {
+		return nil
+	}
+	flat := make(map[string]interface{}, len(options))
+	var flatten func(prefix string, m map[string]interface{})
+	flatten = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]interface{}); ok {
+				flatten(prefix+k+".", sub)
+				continue
+			}
+			flat[prefix+k] = v
+		}
+	}
+	flatten("", options)
+	return flat
+}
+
+//offsetLine returns the line number of a byte offset in data
+func offsetLine(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return strings.Count(string(data[:offset]), "\n") + 1
+}
@@ -3,7 +3,11 @@ package prompt
 import (
 	"bufio"
 	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -75,3 +79,167 @@ func TestConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLayeredConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-config")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"home/goprompt.json":             `{"template":"Evermeet","options":{"path.fullpath":1,"user.option":"x"},"trusted_dirs":["` + dir + `/trusted"]}`,
+		"trusted/repo/.goprompt.yaml":    "template: Prefered\noptions:\n  path.fullpath: 2\n",
+		"untrusted/repo/.goprompt.json":  `{"custom_template":"{{load \"k8s\"}}"}`,
+		"trusted/repo/sub/.keep":         "",
+		"untrusted/repo/sub/other/.keep": "",
+	}
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatalf("unable to create dir: %v", err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write %s: %v", file, err)
+		}
+	}
+
+	tt := []struct {
+		name           string
+		cwd            string
+		expectTemplate string
+		expectOptions  map[string]interface{}
+		expectIgnored  bool
+	}{
+		{
+			name:           "trusted project",
+			cwd:            filepath.Join(dir, "trusted/repo/sub"),
+			expectTemplate: "Prefered",
+			expectOptions:  map[string]interface{}{"path.fullpath": float64(2), "user.option": "x"},
+		},
+		{
+			name:           "untrusted project",
+			cwd:            filepath.Join(dir, "untrusted/repo/sub/other"),
+			expectTemplate: "Evermeet",
+			expectOptions:  map[string]interface{}{"path.fullpath": float64(1), "user.option": "x"},
+			expectIgnored:  true,
+		},
+		{
+			name:           "no project",
+			cwd:            dir,
+			expectTemplate: "Evermeet",
+			expectOptions:  map[string]interface{}{"path.fullpath": float64(1), "user.option": "x"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewLayeredConfig(filepath.Join(dir, "home/goprompt.json"), tc.cwd)
+			if err != nil {
+				t.Fatalf("error loading config: %v", err)
+			}
+			if template, _ := c.GetTemplate(); template != tc.expectTemplate {
+				t.Errorf("expecting template %s got %s", tc.expectTemplate, template)
+			}
+			if options, _ := c.GetOptions(); !reflect.DeepEqual(options, tc.expectOptions) {
+				t.Errorf("expecting options %v got %v", tc.expectOptions, options)
+			}
+			if _, ignored := c.GetUntrusted(); ignored != tc.expectIgnored {
+				t.Errorf("expecting untrusted %v got %v", tc.expectIgnored, ignored)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-validate")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tt := []struct {
+		name   string
+		file   string
+		data   string
+		expect []string
+	}{
+		{
+			name:   "valid json",
+			file:   "goprompt.json",
+			data:   `{"template":"Evermeet","options":{"path.fullpath":2}}`,
+			expect: nil,
+		},
+		{
+			name: "yaml with errors",
+			file: "goprompt.yaml",
+			data: "template: Evermeet\noptions:\n  path.fulpath: 2\n  path.fullpath: \"2\"\ntemplte: x\n",
+			expect: []string{
+				"goprompt.yaml:3: path.fulpath: unknown option",
+				"goprompt.yaml:4: path.fullpath: expecting an integer, got 2 (string)",
+				"goprompt.yaml:5: templte: unknown config key",
+			},
+		},
+		{
+			name: "toml nested options",
+			file: "goprompt.toml",
+			data: "template = \"Evermeet\"\n\n[options.path]\nfullpath = 7\n",
+			expect: []string{
+				"goprompt.toml:4: path.fullpath: invalid value 7, allowed values: 0, 1, 2, 3",
+			},
+		},
+		{
+			name: "yaml nested options after a key of the same name",
+			file: "goprompt.yaml",
+			data: "segments:\n  tag:\n    type: env\n    var: TAG\noptions:\n  git:\n    tag: \"yes\"\n",
+			expect: []string{
+				"goprompt.yaml:7: git.tag: expecting a boolean, got yes (string)",
+			},
+		},
+		{
+			name: "toml nested options after a key of the same name",
+			file: "goprompt.toml",
+			data: "custom_template = \"\"\"\nfullpath = 1\n\"\"\"\n[segments.fullpath]\ntype = \"env\"\nvar = \"X\"\n\n[options]\npath.fullpath = 7\n",
+			expect: []string{
+				"goprompt.toml:9: path.fullpath: invalid value 7, allowed values: 0, 1, 2, 3",
+			},
+		},
+		{
+			name: "json nested options",
+			file: "goprompt.json",
+			data: "{\n  \"segments\": {\"tag\": {\"type\": \"env\", \"var\": \"TAG\"}},\n  \"options\": {\n    \"git\": {\"tag\": 1}\n  }\n}\n",
+			expect: []string{
+				"goprompt.json:4: git.tag: expecting a boolean, got 1 (number)",
+			},
+		},
+		{
+			name: "toml segments",
+			file: "goprompt.toml",
+			data: "[segments]\ntfws = {type = \"exec\", cmd = \"terraform workspace show\", when = \"exists .terraform\", ttl = \"30s\", color = \"yellow\"}\nregion = {type = \"env\"}\ngit = {type = \"env\", var = \"GIT\"}\nslow = {type = \"exec\", cmd = \"x\", ttl = \"soon\"}\n\n[options]\n\"tfws.x\" = 1\n\"tfws.on_error\" = \"show-marker\"\n",
+			expect: []string{
+				"goprompt.toml:3: segments.region: missing var",
+				"goprompt.toml:4: segments.git: there is a built-in plugin called git",
+				"goprompt.toml:5: segments.slow: invalid ttl: expecting a duration: time: invalid duration \"soon\"",
+				"goprompt.toml:8: tfws.x: unknown option",
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(dir, tc.file)
+			if err := ioutil.WriteFile(file, []byte(tc.data), 0644); err != nil {
+				t.Fatalf("unable to write %s: %v", file, err)
+			}
+			c, err := loadConfigFile(file)
+			if err != nil {
+				t.Fatalf("error loading config: %v", err)
+			}
+			var got []string
+			for _, e := range c.Validate() {
+				got = append(got, strings.TrimPrefix(e.Error(), dir+"/"))
+			}
+			if !reflect.DeepEqual(got, tc.expect) {
+				t.Errorf("expecting %q got %q", tc.expect, got)
+			}
+		})
+	}
+}
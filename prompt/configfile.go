@@ -0,0 +1,218 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/josledp/goprompt/prompt/plugin"
+	yaml "gopkg.in/yaml.v2"
+)
+
+//ConfigFile is a config file loaded as a generic document, so it can be edited
+//without losing the keys goprompt does not know about
+type ConfigFile struct {
+	file   string
+	format string
+	raw    map[string]interface{}
+}
+
+//OpenConfigFile loads file for editing. A missing file is opened as an empty config
+func OpenConfigFile(file string) (*ConfigFile, error) {
+	format, ok := configFormats[filepath.Ext(file)]
+	if !ok {
+		format = "json"
+	}
+	cf := &ConfigFile{file: file, format: format, raw: make(map[string]interface{})}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return cf, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config %s: %v", file, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return cf, nil
+	}
+	raw, err := unmarshalRaw(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal config %s: %v", file, err)
+	}
+	if raw != nil {
+		cf.raw = raw
+	}
+	if options, ok := cf.raw["options"].(map[string]interface{}); ok {
+		cf.raw["options"] = flattenOptions(options)
+	}
+	return cf, nil
+}
+
+//Path returns the path of the config file
+func (cf *ConfigFile) Path() string {
+	return cf.file
+}
+
+//Get returns the value of a top level config key (template, custom_template, trusted_dirs)
+//or of a plugin option
+func (cf *ConfigFile) Get(key string) (interface{}, bool) {
+	if _, ok := configKeys[key]; ok {
+		v, ok := cf.raw[key]
+		return v, ok
+	}
+	options, _ := cf.raw["options"].(map[string]interface{})
+	v, ok := options[key]
+	return v, ok
+}
+
+//Set parses value for key and sets it. Plugin options are checked against the type declared
+//by the plugin, and trusted_dirs takes a comma separated list. The external plugins and
+//the segments can not be set
+func (cf *ConfigFile) Set(key, value string) error {
+	if _, ok := configKeys[key]; ok {
+		switch key {
+		case "options":
+			return fmt.Errorf("options can not be set as a whole, set each option by its name")
+		case "plugins", "segments":
+			return fmt.Errorf("%s can not be set from the command line, edit the config file", key)
+		case "trusted_dirs":
+			dirs := make([]interface{}, 0)
+			for _, d := range strings.Split(value, ",") {
+				if d = strings.TrimSpace(d); d != "" {
+					dirs = append(dirs, d)
+				}
+			}
+			cf.raw[key] = dirs
+		default:
+			cf.raw[key] = value
+		}
+		return nil
+	}
+
+	o, ok := cf.option(key)
+	if !ok {
+		return fmt.Errorf("unknown option %s", key)
+	}
+	if _, err := o.Parse(value); err != nil {
+		return fmt.Errorf("option %s: %v", key, err)
+	}
+	options, ok := cf.raw["options"].(map[string]interface{})
+	if !ok {
+		options = make(map[string]interface{})
+		cf.raw["options"] = options
+	}
+	options[key] = rawOptionValue(o, value)
+	return nil
+}
+
+//option returns the option key declared by the plugins, including the error policy of the
+//segments and external plugins known to the config file
+func (cf *ConfigFile) option(key string) (plugin.Option, bool) {
+	if o, ok := declaredOptions()[key]; ok {
+		return o, true
+	}
+	name, ok := errorPolicyPlugin(key)
+	if !ok {
+		return plugin.Option{}, false
+	}
+	segments, _ := cf.raw["segments"].(map[string]interface{})
+	if _, ok := segments[name]; ok {
+		return errorPolicyOption(name), true
+	}
+	configured := make(map[string]string)
+	plugins, _ := cf.raw["plugins"].(map[string]interface{})
+	for n, command := range plugins {
+		if command, ok := command.(string); ok {
+			configured[n] = command
+		}
+	}
+	if _, ok := FindExternalPlugins(os.Getenv("PATH"), configured)[name]; ok {
+		return errorPolicyOption(name), true
+	}
+	return plugin.Option{}, false
+}
+
+//Unset removes key from the config file, returning false if it was not set
+func (cf *ConfigFile) Unset(key string) bool {
+	if _, ok := configKeys[key]; ok {
+		_, found := cf.raw[key]
+		delete(cf.raw, key)
+		return found
+	}
+	options, _ := cf.raw["options"].(map[string]interface{})
+	_, found := options[key]
+	delete(options, key)
+	return found
+}
+
+//Save writes the config file back in its format. The file is replaced atomically
+func (cf *ConfigFile) Save() error {
+	var data []byte
+	var err error
+	switch cf.format {
+	case "yaml":
+		data, err = yaml.Marshal(cf.raw)
+	case "toml":
+		b := &bytes.Buffer{}
+		err = toml.NewEncoder(b).Encode(cf.raw)
+		data = b.Bytes()
+	default:
+		data, err = json.MarshalIndent(cf.raw, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return fmt.Errorf("unable to marshal config: %v", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(cf.file), 0755)
+	if err != nil {
+		return fmt.Errorf("unable to create config path %s: %v", filepath.Dir(cf.file), err)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(cf.file), "."+filepath.Base(cf.file))
+	if err != nil {
+		return fmt.Errorf("unable to create temporary config: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to write config: %v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("unable to write config: %v", err)
+	}
+	if fi, err := os.Stat(cf.file); err == nil {
+		os.Chmod(tmp.Name(), fi.Mode())
+	} else {
+		os.Chmod(tmp.Name(), 0644)
+	}
+	if err = os.Rename(tmp.Name(), cf.file); err != nil {
+		return fmt.Errorf("unable to save config %s: %v", cf.file, err)
+	}
+	return nil
+}
+
+//rawOptionValue returns the value to store in a config file for an option given as a string
+func rawOptionValue(o plugin.Option, value string) interface{} {
+	switch o.Type {
+	case plugin.OptionInt:
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+		b, _ := strconv.ParseBool(value)
+		return b
+	case plugin.OptionBool:
+		b, _ := strconv.ParseBool(value)
+		return b
+	case plugin.OptionDuration:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-configfile")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tt := []struct {
+		name string
+		file string
+		data string
+	}{
+		{
+			name: "json",
+			file: "goprompt.json",
+			data: `{"template":"Evermeet","options":{"path.fullpath":1,"git.fetch_interval":"1m"},"unknown":{"keep":"me"}}`,
+		},
+		{
+			name: "yaml",
+			file: "goprompt.yaml",
+			data: "template: Evermeet\noptions:\n  path:\n    fullpath: 1\n  git.fetch_interval: 1m\nunknown:\n  keep: me\n",
+		},
+		{
+			name: "toml",
+			file: "goprompt.toml",
+			data: "template = \"Evermeet\"\n[options]\n\"path.fullpath\" = 1\n\"git.fetch_interval\" = \"1m\"\n[unknown]\nkeep = \"me\"\n",
+		},
+		{
+			name: "missing",
+			file: "missing.json",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(dir, tc.file)
+			if tc.data != "" {
+				if err := ioutil.WriteFile(file, []byte(tc.data), 0644); err != nil {
+					t.Fatalf("unable to write %s: %v", file, err)
+				}
+			}
+			cf, err := OpenConfigFile(file)
+			if err != nil {
+				t.Fatalf("unable to open config file: %v", err)
+			}
+			if err := cf.Set("path.fullpath", "2"); err != nil {
+				t.Fatalf("unable to set option: %v", err)
+			}
+			if err := cf.Set("path.fullpath", "x"); err == nil {
+				t.Errorf("expecting error setting invalid value")
+			}
+			if err := cf.Set("path.fulpath", "2"); err == nil {
+				t.Errorf("expecting error setting unknown option")
+			}
+			cf.raw["segments"] = map[string]interface{}{"todo": map[string]interface{}{"type": "env", "var": "TODO"}}
+			if err := cf.Set("todo.on_error", "show-marker"); err != nil {
+				t.Errorf("unable to set the segment error policy: %v", err)
+			}
+			if err := cf.Set("nope.on_error", "show-marker"); err == nil {
+				t.Errorf("expecting error setting the error policy of an unknown plugin")
+			}
+			for _, key := range []string{"plugins", "segments"} {
+				if err := cf.Set(key, "weather"); err == nil {
+					t.Errorf("expecting error setting %s", key)
+				}
+			}
+			if err := cf.Set("trusted_dirs", "~/work, /srv"); err != nil {
+				t.Fatalf("unable to set trusted_dirs: %v", err)
+			}
+			cf.Unset("git.fetch_interval")
+			if err := cf.Save(); err != nil {
+				t.Fatalf("unable to save config file: %v", err)
+			}
+
+			cf, err = OpenConfigFile(file)
+			if err != nil {
+				t.Fatalf("unable to reopen config file: %v", err)
+			}
+			if v, _ := cf.Get("path.fullpath"); v != float64(2) {
+				t.Errorf("expecting path.fullpath 2, got %v", v)
+			}
+			if _, ok := cf.Get("git.fetch_interval"); ok {
+				t.Errorf("expecting git.fetch_interval unset")
+			}
+			if v, _ := cf.Get("trusted_dirs"); !reflect.DeepEqual(v, []interface{}{"~/work", "/srv"}) {
+				t.Errorf("expecting trusted_dirs, got %v", v)
+			}
+			if tc.data != "" && !reflect.DeepEqual(cf.raw["unknown"], map[string]interface{}{"keep": "me"}) {
+				t.Errorf("unknown keys not preserved: %v", cf.raw)
+			}
+
+		})
+	}
+}
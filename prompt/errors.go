@@ -0,0 +1,77 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/josledp/goprompt/prompt/plugin"
+)
+
+//Error policies, what to do when a plugin fails to load
+const (
+	//OnErrorHide hides the plugin output
+	OnErrorHide = "hide"
+	//OnErrorShowMarker shows an error marker instead of the plugin output
+	OnErrorShowMarker = "show-marker"
+	//OnErrorFail fails the whole template
+	OnErrorFail = "fail"
+)
+
+//PluginError is the error of a plugin that failed to load
+type PluginError struct {
+	Plugin string
+	Err    error
+}
+
+func (e PluginError) Error() string {
+	return "plugin " + e.Plugin + ": " + e.Err.Error()
+}
+
+//errorPolicyOption returns the option choosing the error policy of the plugin called name
+func errorPolicyOption(name string) plugin.Option {
+	return plugin.Option{
+		Name:        name + ".on_error",
+		Type:        plugin.OptionEnum,
+		Default:     OnErrorHide,
+		Allowed:     []interface{}{OnErrorHide, OnErrorShowMarker, OnErrorFail},
+		Description: "what to do when the plugin fails: hide it, show an error marker instead or fail the whole prompt",
+	}
+}
+
+//errorPolicyPlugin returns the plugin name of key if it is the error policy option of a
+//plugin. Every plugin has one, including the segments and the external plugins
+func errorPolicyPlugin(key string) (string, bool) {
+	name := strings.TrimSuffix(key, ".on_error")
+	return name, name != key && name != "" && !strings.Contains(name, ".")
+}
+
+//errorPolicy returns the error policy of the plugin called name
+func (pr *Prompt) errorPolicy(name string) string {
+	v, ok := pr.options[name+".on_error"]
+	if !ok {
+		return OnErrorHide
+	}
+	policy, err := errorPolicyOption(name).Convert(v)
+	if err != nil {
+		if pr.debug {
+			fmt.Fprintf(os.Stderr, "option %s.on_error: %v, using default %v\n", name, err, OnErrorHide)
+		}
+		return OnErrorHide
+	}
+	return policy.(string)
+}
+
+//Errors returns the errors of the plugins that failed to load in the last compiled template
+func (pr *Prompt) Errors() []PluginError {
+	return pr.failed
+}
+
+//ErrorsSegment returns an error marker if any of the plugins loaded so far failed, so it
+//is meant to be placed after them in the template
+func (pr *Prompt) ErrorsSegment() string {
+	if len(pr.failed) == 0 {
+		return ""
+	}
+	return pr.format(pr.icons.Icon("error"), "status.error")
+}
@@ -0,0 +1,55 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/plugin"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+type failingPlugin struct {
+	fakePlugin
+}
+
+func (f failingPlugin) Load(pr plugin.Prompter) error {
+	return errors.New("broken")
+}
+
+func TestErrorPolicy(t *testing.T) {
+	testCases := []struct {
+		policy   interface{}
+		expected string
+	}{
+		{policy: nil, expected: "A|!$ "},
+		{policy: OnErrorHide, expected: "A|!$ "},
+		{policy: OnErrorShowMarker, expected: "A(!)|!$ "},
+		{policy: OnErrorFail, expected: "[template error] $ "},
+		{policy: "explode", expected: "A|!$ "},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprint(tc.policy), func(t *testing.T) {
+			options := map[string]interface{}{}
+			if tc.policy != nil {
+				options["x.on_error"] = tc.policy
+			}
+			pr := Prompt{
+				options: resolveOptions(options, false),
+				cache:   &Cache{},
+				plugins: map[string]Plugin{
+					"a": fakePlugin{name: "a", output: "A", role: "a"},
+					"x": failingPlugin{fakePlugin{name: "x", output: "X"}},
+				},
+				format: theme.Default().Formatter(theme.Renderer{Target: theme.Plain}),
+			}
+			if output := pr.Compile(`{{load "a"}}{{load "x"|wrap "(" ")"}}|{{errors}}$ `); output != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, output)
+			}
+			errs := pr.Errors()
+			if len(errs) != 1 || errs[0].Error() != "plugin x: broken" {
+				t.Errorf("expected the x plugin error, got %v", errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+//PluginReport is what goprompt explain reports about a plugin loaded by the template
+type PluginReport struct {
+	Name     string
+	Duration time.Duration
+	Cached   bool
+	Options  map[string]interface{}
+	Raw      string
+	Rendered string
+	Error    error
+}
+
+//Explanation is the rendered prompt along with the time it took and a report of every plugin it loaded
+type Explanation struct {
+	Prompt   string
+	Duration time.Duration
+	Plugins  []PluginReport
+}
+
+//Explain reports the last compiled template: the prompt, the time it took and every plugin it loaded
+func (pr *Prompt) Explain() Explanation {
+	e := Explanation{Prompt: pr.rendered, Duration: pr.elapsed}
+	for _, l := range pr.loaded {
+		e.Plugins = append(e.Plugins, PluginReport{
+			Name:     l.name,
+			Duration: l.duration,
+			Cached:   l.cached,
+			Options:  pr.pluginOptions(l.name),
+			Raw:      escapeRe.ReplaceAllString(l.output, ""),
+			Rendered: l.output,
+			Error:    l.err,
+		})
+	}
+	return e
+}
+
+//pluginOptions returns the options in effect for the plugin called name
+func (pr *Prompt) pluginOptions(name string) map[string]interface{} {
+	options := make(map[string]interface{})
+	for k, v := range pr.options {
+		if strings.HasPrefix(k, name+".") {
+			options[k] = v
+		}
+	}
+	return options
+}
+
+//Write writes the explanation as a human readable report
+func (e Explanation) Write(w io.Writer) {
+	fmt.Fprintf(w, "prompt: %q\n", e.Prompt)
+	fmt.Fprintf(w, "total: %v\n", e.Duration)
+	for _, p := range e.Plugins {
+		cached := ""
+		if p.Cached {
+			cached = " (cached)"
+		}
+		fmt.Fprintf(w, "\n%s: %v%s\n", p.Name, p.Duration, cached)
+		if p.Error != nil {
+			fmt.Fprintf(w, "  error: %v\n", p.Error)
+		}
+		keys := make([]string, 0, len(p.Options))
+		for k := range p.Options {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(w, "  option %s = %v\n", k, p.Options[k])
+		}
+		fmt.Fprintf(w, "  raw: %q\n", p.Raw)
+		fmt.Fprintf(w, "  rendered: %q\n", p.Rendered)
+	}
+}
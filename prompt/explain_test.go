@@ -0,0 +1,70 @@
+package prompt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/plugin"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+//cachedPlugin reads the cache, reporting the hit if it reuses the value
+type cachedPlugin struct {
+	fakePlugin
+	reuse bool
+}
+
+func (c cachedPlugin) Load(pr plugin.Prompter) error {
+	pr.GetCache("cached")
+	if r, ok := pr.(plugin.CacheReporter); ok && c.reuse {
+		r.CacheHit()
+	}
+	return nil
+}
+
+func TestCompileExplain(t *testing.T) {
+	th := theme.Default()
+	pr := Prompt{
+		cache: &Cache{data: map[string]interface{}{"cached": true}},
+		plugins: map[string]Plugin{
+			"a": fakePlugin{name: "a", output: "A", role: "status.error"},
+			"c": cachedPlugin{fakePlugin{name: "c", output: "C", role: "c"}, true},
+			"r": cachedPlugin{fakePlugin{name: "r"}, false},
+			"x": failingPlugin{fakePlugin{name: "x"}},
+		},
+		options: map[string]interface{}{"a.width": 3, "ab.width": 4},
+		format:  th.Formatter(theme.Renderer{Target: theme.ANSI}),
+		theme:   th,
+	}
+
+	pr.Compile(`{{load "a"}}{{load "c"}}{{load "r"}}{{load "x"}}$ `)
+	e := pr.Explain()
+	if e.Prompt != "\033[0m\033[91mA\033[0mC$ " {
+		t.Errorf("unexpected prompt %q", e.Prompt)
+	}
+	if len(e.Plugins) != 4 {
+		t.Fatalf("expected 4 plugins, got %+v", e.Plugins)
+	}
+	a, c, r, x := e.Plugins[0], e.Plugins[1], e.Plugins[2], e.Plugins[3]
+	if a.Cached || !c.Cached || r.Cached {
+		t.Errorf("expected only c to be cached, got a %v c %v r %v", a.Cached, c.Cached, r.Cached)
+	}
+	if a.Raw != "A" || a.Rendered != "\033[0m\033[91mA\033[0m" {
+		t.Errorf("unexpected output raw %q rendered %q", a.Raw, a.Rendered)
+	}
+	if len(a.Options) != 1 || a.Options["a.width"] != 3 {
+		t.Errorf("unexpected options %v", a.Options)
+	}
+	if x.Error == nil || x.Error.Error() != "broken" {
+		t.Errorf("expected x error, got %v", x.Error)
+	}
+
+	b := &bytes.Buffer{}
+	e.Write(b)
+	for _, s := range []string{"\nc: ", " (cached)\n", "  option a.width = 3\n", "  error: broken\n", `  raw: "A"`} {
+		if !strings.Contains(b.String(), s) {
+			t.Errorf("expected %q in report:\n%s", s, b.String())
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package prompt
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/josledp/goprompt/prompt/plugin"
+)
+
+//FindExternalPlugins returns the command of every external plugin by name: the
+//goprompt-plugin-<name> executables on path (the first one found for each name) and the
+//configured ones, which take precedence. Built-in plugins can not be replaced
+func FindExternalPlugins(path string, configured map[string]string) map[string]string {
+	commands := make(map[string]string)
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name := strings.TrimPrefix(f.Name(), plugin.ExternalPrefix)
+			if name == f.Name() || name == "" || f.IsDir() || f.Mode()&0111 == 0 {
+				continue
+			}
+			if _, ok := commands[name]; !ok {
+				commands[name] = filepath.Join(dir, f.Name())
+			}
+		}
+	}
+	for name, command := range configured {
+		commands[name] = expandHome(command)
+	}
+	for _, r := range availablePlugins {
+		delete(commands, r.name)
+	}
+	return commands
+}
+
+//AddExternalPlugins makes the external plugins (their command by name) available to the templates
+func (pr *Prompt) AddExternalPlugins(commands map[string]string) {
+	for name, command := range commands {
+		if _, ok := pr.plugins[name]; ok {
+			continue
+		}
+		pr.plugins[name] = plugin.NewExternal(name, command, pr.pluginOptions(name))
+	}
+}
+
+//LookupExternalPlugins makes the templates find the goprompt-plugin-<name> executables on
+//PATH for the plugins not available otherwise, each one looked up the first time it is used.
+//Unlike FindExternalPlugins it does not read every PATH directory
+func (pr *Prompt) LookupExternalPlugins() {
+	pr.lookPath = exec.LookPath
+}
+
+//externalPlugin looks up the executable of the external plugin name and makes it available
+func (pr *Prompt) externalPlugin(name string) (Plugin, bool) {
+	if pr.lookPath == nil || name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, false
+	}
+	command, err := pr.lookPath(plugin.ExternalPrefix + name)
+	if err != nil {
+		return nil, false
+	}
+	p := plugin.NewExternal(name, command, pr.pluginOptions(name))
+	pr.plugins[name] = p
+	return p, true
+}
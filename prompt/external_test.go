@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/plugin"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestFindExternalPlugins(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-external")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	dirs := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}
+	files := map[string]os.FileMode{
+		"a/goprompt-plugin-weather": 0755,
+		"a/goprompt-plugin-noexec":  0644,
+		"a/goprompt-plugin-git":     0755,
+		"a/other":                   0755,
+		"b/goprompt-plugin-weather": 0755,
+		"b/goprompt-plugin-vpn":     0755,
+	}
+	for _, d := range dirs {
+		os.Mkdir(d, 0755)
+	}
+	for name, mode := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, mode); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+
+	path := dirs[0] + string(os.PathListSeparator) + dirs[1]
+	commands := FindExternalPlugins(path, map[string]string{"vpn": "/opt/vpn-status", "path": "/bin/pwd"})
+	expected := map[string]string{
+		"weather": filepath.Join(dir, "a/goprompt-plugin-weather"),
+		"vpn":     "/opt/vpn-status",
+	}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Expected %v\nGot      %v", expected, commands)
+	}
+}
+
+func TestLookupExternalPlugins(t *testing.T) {
+	c, _ := NewCache("")
+	pr := New(nil, nil, icon.Set{}, theme.Plain, false, WithCache(c))
+	pr.LookupExternalPlugins()
+	var looked []string
+	pr.lookPath = func(file string) (string, error) {
+		looked = append(looked, file)
+		if file == "goprompt-plugin-weather" {
+			return "/opt/bin/" + file, nil
+		}
+		return "", exec.ErrNotFound
+	}
+
+	pr.Compile(`{{load "git"}}{{has "weather"}}{{load "weather"}}{{load "vpn"}}{{load "../vpn"}}`)
+	expected := []string{"goprompt-plugin-weather", "goprompt-plugin-vpn"}
+	if !reflect.DeepEqual(looked, expected) {
+		t.Errorf("Expected lookups %v\nGot              %v", expected, looked)
+	}
+	if p, ok := pr.plugins["weather"].(*plugin.External); !ok || p.Name() != "weather" {
+		t.Errorf("expected the weather external plugin, got %v", pr.plugins["weather"])
+	}
+	if _, ok := pr.plugins["vpn"]; ok {
+		t.Errorf("expected no vpn plugin")
+	}
+}
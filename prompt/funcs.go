@@ -0,0 +1,114 @@
+package prompt
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+//escapeRe matches the style markup of every target (bash, ansi and tmux), which the text
+//functions keep untouched and do not count as visible characters
+var escapeRe = regexp.MustCompile(`\\\[.*?\\\]|\\033\[[0-9;]*m|\x1b\[[0-9;]*m|#\[[^\]]*\]`)
+
+//mapText applies f to the visible text of s, keeping its style markup
+func mapText(s string, f func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range escapeRe.FindAllStringIndex(s, -1) {
+		b.WriteString(f(s[last:loc[0]]))
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(f(s[last:]))
+	return b.String()
+}
+
+//visibleLen returns the number of visible characters of s
+func visibleLen(s string) int {
+	n := 0
+	mapText(s, func(text string) string {
+		n += utf8.RuneCountInString(text)
+		return text
+	})
+	return n
+}
+
+//Color renders input with a theme role or a style spec (e.g. "git.branch", "bold red", "#ff8700")
+func (pr *Prompt) Color(style, input string) string {
+	if input == "" {
+		return ""
+	}
+	return pr.format(input, theme.Role(style))
+}
+
+//Bold renders input in bold
+func (pr *Prompt) Bold(input string) string {
+	return pr.Color("bold", input)
+}
+
+//Has returns true if plugin has any output
+func (pr *Prompt) Has(plugin string) (bool, error) {
+	output, err := pr.Load(plugin)
+	return output != "", err
+}
+
+//Truncate keeps the first n visible characters of input
+func Truncate(n int, input string) string {
+	return mapText(input, func(text string) string {
+		if n <= 0 {
+			return ""
+		}
+		i := 0
+		for pos := range text {
+			if i == n {
+				n = 0
+				return text[:pos]
+			}
+			i++
+		}
+		n -= i
+		return text
+	})
+}
+
+//Ellipsis truncates input to n visible characters, ending with … when it is shortened
+func Ellipsis(n int, input string) string {
+	if visibleLen(input) <= n {
+		return input
+	}
+	if n <= 0 {
+		return Truncate(0, input)
+	}
+	return Truncate(n-1, input) + "…"
+}
+
+//Upper converts input to upper case
+func Upper(input string) string {
+	return mapText(input, strings.ToUpper)
+}
+
+//Lower converts input to lower case
+func Lower(input string) string {
+	return mapText(input, strings.ToLower)
+}
+
+//Default returns input, or value if input is empty
+func Default(value, input string) string {
+	if input == "" {
+		return value
+	}
+	return input
+}
+
+//Join joins the non empty inputs with sep
+func Join(sep string, inputs ...string) string {
+	parts := make([]string, 0, len(inputs))
+	for _, s := range inputs {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"os"
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	os.Setenv("GOPROMPT_TEST_VAR", "value")
+	defer os.Unsetenv("GOPROMPT_TEST_VAR")
+
+	th := theme.Default()
+	testCases := []struct {
+		name     string
+		target   theme.Target
+		tmpl     string
+		expected string
+	}{
+		{name: "color", target: theme.ANSI, tmpl: `{{color "red" "x"}}{{color "red" ""}}`, expected: "\033[0m\033[31mx\033[0m"},
+		{name: "color role", target: theme.ANSI, tmpl: `{{"x" | color "status.error"}}`, expected: "\033[0m\033[91mx\033[0m"},
+		{name: "bold", target: theme.ANSI, tmpl: `{{bold "x"}}`, expected: "\033[0m\033[1mx\033[0m"},
+		{name: "truncate", target: theme.Plain, tmpl: `{{truncate 3 "abcdef"}}|{{truncate 10 "abc"}}`, expected: "abc|abc"},
+		{name: "truncate keeps escapes", target: theme.Bash, tmpl: `{{color "red" "abcdef" | truncate 2}}`, expected: "\\[\\033[0m\\]\\[\\033[31m\\]ab\\[\\033[0m\\]"},
+		{name: "ellipsis", target: theme.Plain, tmpl: `{{ellipsis 4 "abcdef"}}|{{ellipsis 6 "abcdef"}}`, expected: "abc…|abcdef"},
+		{name: "upper lower", target: theme.ANSI, tmpl: `{{upper (bold "ab")}}{{lower "CD"}}`, expected: "\033[0m\033[1mAB\033[0mcd"},
+		{name: "env", target: theme.Plain, tmpl: `{{env "GOPROMPT_TEST_VAR"}}`, expected: "value"},
+		{name: "has", target: theme.Plain, tmpl: `{{if has "a"}}a{{end}}{{if has "empty"}}empty{{end}}`, expected: "a"},
+		{name: "default", target: theme.Plain, tmpl: `{{load "empty" | default "none"}} {{load "a" | default "none"}}`, expected: "none A"},
+		{name: "join", target: theme.Plain, tmpl: `{{join " | " (load "a") (load "empty") (load "b")}}`, expected: "A | B"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := Prompt{
+				cache: &Cache{},
+				plugins: map[string]Plugin{
+					"a":     fakePlugin{name: "a", output: "A", role: "a"},
+					"b":     fakePlugin{name: "b", output: "B", role: "b"},
+					"empty": fakePlugin{name: "empty"},
+				},
+				format: th.Formatter(theme.Renderer{Target: tc.target}),
+				theme:  th,
+			}
+			if output := pr.Compile(tc.tmpl); output != tc.expected {
+				t.Errorf("Expected %q\nGot      %q", tc.expected, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package prompt
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/internal/fixture"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+//goldenShells are the shells the built-in templates are rendered for
+var goldenShells = []string{"bash", "zsh", "fish", "plain"}
+
+var goldenClock = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+//goldenScenario is the environment a template is rendered in: the variables, the files,
+//options on top of the template ones and, if repo is set, the state of the git repository
+//in the working directory
+type goldenScenario struct {
+	name    string
+	env     map[string]string
+	files   fstest.MapFS
+	options map[string]interface{}
+	repo    fixture.State
+}
+
+func goldenScenarios() []goldenScenario {
+	scenarios := []goldenScenario{
+		{name: "home"},
+		{name: "failed", env: map[string]string{"LAST_COMMAND_RC": "127"}},
+		{name: "root", env: map[string]string{"USER": "root"}},
+		{
+			name: "environments",
+			env: map[string]string{
+				"VIRTUAL_ENV":        "/home/me/.venvs/goprompt",
+				"AWS_ROLE":           "company:role-dev-admin",
+				"AWS_SESSION_EXPIRE": strconv.FormatInt(goldenClock.Add(time.Hour).Unix(), 10),
+			},
+			files: fstest.MapFS{"home/me/.kube/config": &fstest.MapFile{Data: []byte("current-context: prod\ncontexts:\n- name: prod\n  context:\n    namespace: web\n")}},
+		},
+	}
+	for _, s := range fixture.States {
+		scenarios = append(scenarios, goldenScenario{name: "git-" + string(s), repo: s})
+	}
+	scenarios = append(scenarios, goldenScenario{
+		name:    "git-release",
+		options: map[string]interface{}{"git.tag": true, "git.describe": true, "git.last_commit": true},
+		repo:    fixture.Tagged,
+	})
+	return scenarios
+}
+
+//render compiles tmpl for shell in the scenario, with the working directory in dir
+func (s goldenScenario) render(name, tmpl, shell, dir string) string {
+	env := map[string]string{
+		"USER":            "me",
+		"HOME":            "/home/me",
+		"PWD":             "/home/me/src/goprompt",
+		"LAST_COMMAND_RC": "0",
+	}
+	for k, v := range s.env {
+		env[k] = v
+	}
+	files := s.files
+	if files == nil {
+		files = fstest.MapFS{}
+	}
+	options, _ := GetTemplateOptions(name)
+	c, _ := NewCache("")
+	pr := New(MergeOptions(
+		OptionLayer{Source: "template", Options: options},
+		OptionLayer{Source: "test", Options: map[string]interface{}{"git.fetch_interval": "0s"}},
+		OptionLayer{Source: "scenario", Options: s.options},
+	), nil, icon.Set{}, shellTarget(shell), false,
+		WithCache(c),
+		WithEnv(func(name string) string { return env[name] }),
+		WithCwd(dir),
+		WithHostname("box.example.com"),
+		WithClock(func() time.Time { return goldenClock }),
+		WithFS(files),
+	)
+	return pr.Compile(tmpl)
+}
+
+//TestGolden renders every built-in template for every shell in every scenario and compares
+//the output to testdata/golden/<template>.golden. Run go test -update to rewrite them
+func TestGolden(t *testing.T) {
+	scenarios := goldenScenarios()
+	//the git plugin reads the repository from the real filesystem, the rest do not need it
+	dirs := make(map[string]string, len(scenarios))
+	for _, s := range scenarios {
+		dir, err := ioutil.TempDir("", "goprompt-golden")
+		if err != nil {
+			t.Fatalf("unable to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+		if s.repo != "" {
+			dir = fixture.Init(t, filepath.Join(dir, "goprompt"), s.repo)
+		}
+		dirs[s.name] = dir
+	}
+
+	for _, name := range GetDefaultTemplates() {
+		tmpl, _ := GetTemplate(name)
+		var lines []string
+		for _, s := range scenarios {
+			for _, shell := range goldenShells {
+				lines = append(lines, fmt.Sprintf("%s/%s: %q", s.name, shell, s.render(name, tmpl, shell, dirs[s.name])))
+			}
+		}
+		got := strings.Join(lines, "\n") + "\n"
+
+		file := filepath.Join("testdata", "golden", name+".golden")
+		if *update {
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				t.Fatalf("unable to create golden dir: %v", err)
+			}
+			if err := ioutil.WriteFile(file, []byte(got), 0644); err != nil {
+				t.Fatalf("unable to update %s: %v", file, err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("unable to read %s (go test -update creates it): %v", file, err)
+		}
+		wantLines := strings.Split(strings.TrimSuffix(string(want), "\n"), "\n")
+		for i, line := range lines {
+			if i >= len(wantLines) {
+				t.Errorf("%s: unexpected line %s", name, line)
+			} else if line != wantLines[i] {
+				t.Errorf("%s: got\n%s\nwant\n%s", name, line, wantLines[i])
+			}
+		}
+		if len(wantLines) > len(lines) {
+			t.Errorf("%s: missing lines %v", name, wantLines[len(lines):])
+		}
+	}
+}
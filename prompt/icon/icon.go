@@ -0,0 +1,150 @@
+// Package icon holds the symbols plugins draw (git status marks, segment icons...) in
+// several sets, so the prompt can use Nerd Font glyphs or stay plain ASCII depending on
+// the font and terminal
+package icon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Built-in icon set names
+const (
+	NerdFont = "nerdfont"
+	Unicode  = "unicode"
+	ASCII    = "ascii"
+)
+
+// DefaultName is the name of the default icon set
+const DefaultName = Unicode
+
+// Set is a named set of icons by name. The zero value is the default set
+type Set struct {
+	Name  string
+	icons map[string]string
+}
+
+// sets are the built-in icon sets. The segment icons (git.branch, aws, k8s...) are empty
+// in the unicode and ascii sets, so the plugins output only their text
+var sets = map[string]map[string]string{
+	Unicode: {
+		"git.branch":     "",
+		"git.behind":     "↓·",
+		"git.ahead":      "↑·",
+		"git.noupstream": "⭑",
+		"git.conflicted": "✖",
+		"git.staged":     "●",
+		"git.changed":    "+",
+		"git.untracked":  "…",
+		"git.clean":      "✔",
+		"git.stashed":    "⚑",
+		"git.submodules": "⊞",
+		"git.bare":       "∅",
+		"git.worktree":   "⧉",
+		"git.submodule":  "⊂",
+		"git.unrecorded": "≠",
+		"git.tag":        "#",
+		"git.commit":     "@",
+		"aws":            "",
+		"k8s":            "",
+		"python":         "",
+		"golang":         "",
+		"error":          "!",
+	},
+	NerdFont: {
+		"git.branch":     "\ue0a0",
+		"git.behind":     "\uf063",
+		"git.ahead":      "\uf062",
+		"git.noupstream": "\uf069",
+		"git.conflicted": "\uf00d",
+		"git.staged":     "\uf111",
+		"git.changed":    "\uf044",
+		"git.untracked":  "\uf128",
+		"git.clean":      "\uf00c",
+		"git.stashed":    "\uf024",
+		"git.submodules": "\uf1b3",
+		"git.bare":       "\uf187",
+		"git.worktree":   "\uf1bb",
+		"git.submodule":  "\uf1d3",
+		"git.unrecorded": "\uf0ec",
+		"git.tag":        "\uf02b",
+		"git.commit":     "\uf417",
+		"aws":            "\uf0c2",
+		"k8s":            "\U000f0833",
+		"python":         "\ue73c",
+		"golang":         "\ue627",
+		"error":          "\uf071",
+	},
+	ASCII: {
+		"git.branch":     "",
+		"git.behind":     "v",
+		"git.ahead":      "^",
+		"git.noupstream": "*",
+		"git.conflicted": "x",
+		"git.staged":     "o",
+		"git.changed":    "+",
+		"git.untracked":  "?",
+		"git.clean":      "=",
+		"git.stashed":    "s",
+		"git.submodules": "m",
+		"git.bare":       "bare",
+		"git.worktree":   "wt:",
+		"git.submodule":  "sub:",
+		"git.unrecorded": "!=",
+		"git.tag":        "#",
+		"git.commit":     "@",
+		"aws":            "",
+		"k8s":            "",
+		"python":         "",
+		"golang":         "",
+		"error":          "!",
+	},
+}
+
+// Names returns the names of the built-in icon sets
+func Names() []string {
+	names := make([]string, 0, len(sets))
+	for name := range sets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the built-in icon set called name
+func Load(name string) (Set, error) {
+	icons, ok := sets[name]
+	if !ok {
+		return Set{}, fmt.Errorf("unknown icon set %s, expected one of %s", name, strings.Join(Names(), ", "))
+	}
+	return Set{Name: name, icons: icons}, nil
+}
+
+// Detect returns the icon set fitting the terminal: ascii on consoles without the
+// unicode glyphs (TERM=linux, vt100...), the default set otherwise
+func Detect(getenv func(string) string) string {
+	term := getenv("TERM")
+	//vt followed by a digit are the DEC terminals, vte-256color is a unicode terminal
+	if term == "linux" || term == "dumb" || (strings.HasPrefix(term, "vt") && len(term) > 2 && term[2] >= '0' && term[2] <= '9') {
+		return ASCII
+	}
+	return DefaultName
+}
+
+// Icon returns the icon called name, empty if the set has none
+func (s Set) Icon(name string) string {
+	if s.icons == nil {
+		return sets[DefaultName][name]
+	}
+	return s.icons[name]
+}
+
+// Prefix returns the icon called name followed by a space, or nothing when the set has
+// no such icon. Meant for the icons drawn before a segment text
+func (s Set) Prefix(name string) string {
+	if i := s.Icon(name); i != "" {
+		return i + " "
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package icon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSets(t *testing.T) {
+	for _, name := range Names() {
+		s, err := Load(name)
+		if err != nil {
+			t.Fatalf("unable to load icon set %s: %v", name, err)
+		}
+		for icon := range sets[DefaultName] {
+			if _, ok := s.icons[icon]; !ok {
+				t.Errorf("icon set %s has no %s icon", name, icon)
+			}
+		}
+		if name != ASCII {
+			continue
+		}
+		for icon, v := range s.icons {
+			if strings.IndexFunc(v, func(r rune) bool { return r > 127 }) >= 0 {
+				t.Errorf("ascii icon %s is not ascii: %q", icon, v)
+			}
+		}
+	}
+
+	if _, err := Load("emoji"); err == nil {
+		t.Errorf("expected error loading an unknown icon set")
+	}
+	if i := (Set{}).Icon("git.clean"); i != "✔" {
+		t.Errorf("expected the zero set to be the unicode one, got %s", i)
+	}
+	s, _ := Load(NerdFont)
+	if p := s.Prefix("aws"); p != "\uf0c2 " {
+		t.Errorf("expected aws prefix with a trailing space, got %q", p)
+	}
+	if p := (Set{}).Prefix("aws"); p != "" {
+		t.Errorf("expected no aws prefix in the unicode set, got %q", p)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		term     string
+		expected string
+	}{
+		{term: "linux", expected: ASCII},
+		{term: "vt220", expected: ASCII},
+		{term: "vt100", expected: ASCII},
+		{term: "vte-256color", expected: Unicode},
+		{term: "vte", expected: Unicode},
+		{term: "xterm-256color", expected: Unicode},
+		{term: "", expected: Unicode},
+	}
+	for _, tc := range testCases {
+		getenv := func(string) string { return tc.term }
+		if s := Detect(getenv); s != tc.expected {
+			t.Errorf("TERM=%s: expected %s, got %s", tc.term, tc.expected, s)
+		}
+	}
+}
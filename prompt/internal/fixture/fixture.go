@@ -0,0 +1,251 @@
+// Package fixture generates the git repositories the benchmarks and golden tests run in
+package fixture
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Repo describes the repository to generate: the number of commits and files, and how
+// many of those files are left changed, staged and untracked
+type Repo struct {
+	Commits   int
+	Files     int
+	Changed   int
+	Staged    int
+	Untracked int
+}
+
+// Small and Large are the repositories the benchmarks use
+var (
+	Small = Repo{Commits: 5, Files: 10, Changed: 1, Staged: 1, Untracked: 1}
+	Large = Repo{Commits: 200, Files: 2000, Changed: 50, Staged: 20, Untracked: 50}
+)
+
+// Generate creates the repository in a temporary directory, removed when the benchmark
+// or test ends, and returns it. It is skipped if git is not installed
+func Generate(tb testing.TB, r Repo) string {
+	tb.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git not installed")
+	}
+	dir, err := ioutil.TempDir("", "goprompt-fixture")
+	if err != nil {
+		tb.Fatalf("unable to create temp dir: %v", err)
+	}
+	tb.Cleanup(func() { os.RemoveAll(dir) })
+
+	g := generator{dir: dir}
+	g.git("init", "-q")
+	for c := 0; c < r.Commits; c++ {
+		//every commit touches a slice of the files, so all of them are committed at the end
+		for f := c * r.Files / r.Commits; f < (c+1)*r.Files/r.Commits; f++ {
+			g.write(f, c)
+		}
+		g.git("add", "-A")
+		g.git("commit", "-q", "--allow-empty", "-m", fmt.Sprintf("commit %d", c))
+	}
+	for f := 0; f < r.Changed && f < r.Files; f++ {
+		g.write(f, r.Commits)
+	}
+	for f := r.Changed; f < r.Changed+r.Staged && f < r.Files; f++ {
+		g.write(f, r.Commits)
+		g.git("add", g.name(f))
+	}
+	for f := r.Files; f < r.Files+r.Untracked; f++ {
+		g.write(f, r.Commits)
+	}
+	if g.err != nil {
+		tb.Fatalf("unable to generate repository: %v", g.err)
+	}
+	return dir
+}
+
+// State is the state of a repository created by Init
+type State string
+
+// The states Init creates a repository in. Dirty has changed, staged and untracked files,
+// Diverged is one commit ahead and two behind its upstream and Detached has its HEAD
+// detached at the previous commit. Worktree is a linked worktree and Bare a bare clone of
+// a repository created next to it, Submodule is a submodule with a commit its
+// superproject does not record and Tagged is a commit after the annotated tag v1.0
+const (
+	Clean      State = "clean"
+	Dirty      State = "dirty"
+	Conflicted State = "conflicted"
+	Stashed    State = "stashed"
+	Diverged   State = "diverged"
+	Detached   State = "detached"
+	Worktree   State = "worktree"
+	Submodule  State = "submodule"
+	Bare       State = "bare"
+	Tagged     State = "tagged"
+)
+
+// States are all the states Init creates
+var States = []State{Clean, Dirty, Conflicted, Stashed, Diverged, Detached, Worktree, Submodule, Bare, Tagged}
+
+// Init creates a repository in dir, on the branch main, in the state s, and returns the
+// directory to work in: dir, or the submodule in it. The commit dates are fixed, so the
+// commit ids are the same on every run
+func Init(tb testing.TB, dir string, s State) string {
+	tb.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		tb.Skip("git not installed")
+	}
+
+	g := generator{dir: dir}
+	if s == Worktree || s == Bare {
+		g.dir = dir + "-main"
+	}
+	if err := os.MkdirAll(g.dir, 0755); err != nil {
+		tb.Fatalf("unable to create %s: %v", g.dir, err)
+	}
+	g.init()
+	workdir := dir
+	switch s {
+	case Dirty:
+		g.write(0, 3)
+		g.write(1, 3)
+		g.git("add", g.name(1))
+		g.write(2, 3)
+	case Conflicted:
+		g.git("checkout", "-q", "-b", "other")
+		g.commit(0, 3)
+		g.git("checkout", "-q", "main")
+		g.commit(0, 4)
+		//the merge fails because of the conflict, leaving it unresolved
+		g.run(false, "merge", "-q", "other")
+	case Stashed:
+		g.write(0, 3)
+		g.git("stash", "-q")
+		g.write(1, 3)
+		g.git("stash", "-q")
+	case Diverged:
+		g.git("branch", "upstream")
+		g.git("checkout", "-q", "upstream")
+		g.commit(1, 3)
+		g.commit(1, 4)
+		g.git("checkout", "-q", "main")
+		g.commit(0, 3)
+		g.git("branch", "-q", "--set-upstream-to", "upstream")
+	case Detached:
+		g.git("checkout", "-q", "--detach", "HEAD~1")
+	case Worktree:
+		g.git("worktree", "add", "-q", dir)
+	case Bare:
+		g.git("clone", "-q", "--bare", g.dir, dir)
+	case Tagged:
+		g.git("tag", "-a", "-m", "release 1.0", "v1.0", "HEAD~1")
+	case Submodule:
+		lib := generator{dir: dir + "-lib"}
+		if lib.err = os.MkdirAll(lib.dir, 0755); lib.err == nil {
+			lib.init()
+		}
+		if g.err == nil {
+			g.err = lib.err
+		}
+		g.git("-c", "protocol.file.allow=always", "submodule", "add", "-q", lib.dir, "lib")
+		g.git("commit", "-q", "-m", "add lib")
+		workdir = filepath.Join(dir, "lib")
+		sub := generator{dir: workdir}
+		sub.commit(2, 3)
+		if g.err == nil {
+			g.err = sub.err
+		}
+	}
+	if g.err != nil {
+		tb.Fatalf("unable to generate repository: %v", g.err)
+	}
+	return workdir
+}
+
+// Git runs git with args in the repository in dir, with the environment Init uses
+func Git(tb testing.TB, dir string, args ...string) {
+	tb.Helper()
+	g := generator{dir: dir}
+	g.git(args...)
+	if g.err != nil {
+		tb.Fatalf("unable to run git: %v", g.err)
+	}
+}
+
+// Chdir changes to dir, as the plugins look for the repository in the current directory,
+// going back when the benchmark or test ends
+func Chdir(tb testing.TB, dir string) {
+	tb.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		tb.Fatalf("unable to get current directory: %v", err)
+	}
+	pwd := os.Getenv("PWD")
+	if err := os.Chdir(dir); err != nil {
+		tb.Fatalf("unable to change to %s: %v", dir, err)
+	}
+	os.Setenv("PWD", dir)
+	tb.Cleanup(func() {
+		os.Chdir(cwd)
+		os.Setenv("PWD", pwd)
+	})
+}
+
+// generator runs the commands creating a repository, keeping the first error
+type generator struct {
+	dir string
+	err error
+}
+
+func (g *generator) name(f int) string {
+	return filepath.Join(fmt.Sprintf("dir%d", f%10), fmt.Sprintf("file%d.txt", f))
+}
+
+func (g *generator) write(f, version int) {
+	if g.err != nil {
+		return
+	}
+	path := filepath.Join(g.dir, g.name(f))
+	if g.err = os.MkdirAll(filepath.Dir(path), 0755); g.err != nil {
+		return
+	}
+	g.err = ioutil.WriteFile(path, []byte(fmt.Sprintf("file %d version %d\n", f, version)), 0644)
+}
+
+// init creates the repository with two commits on the branch main
+func (g *generator) init() {
+	g.git("init", "-q")
+	g.git("symbolic-ref", "HEAD", "refs/heads/main")
+	g.commit(0, 1)
+	g.commit(1, 2)
+}
+
+// commit writes the file f with version and commits it
+func (g *generator) commit(f, version int) {
+	g.write(f, version)
+	g.git("add", g.name(f))
+	g.git("commit", "-q", "-m", fmt.Sprintf("commit %d", version))
+}
+
+func (g *generator) git(args ...string) {
+	g.run(true, args...)
+}
+
+// run runs git with args, keeping its error if mustSucceed
+func (g *generator) run(mustSucceed bool, args ...string) {
+	if g.err != nil {
+		return
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=goprompt", "GIT_AUTHOR_EMAIL=goprompt@example.com",
+		"GIT_COMMITTER_NAME=goprompt", "GIT_COMMITTER_EMAIL=goprompt@example.com",
+		"GIT_AUTHOR_DATE=2020-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2020-01-01T00:00:00Z",
+		"GIT_CONFIG_NOSYSTEM=1", "HOME="+g.dir, "XDG_CONFIG_HOME="+g.dir)
+	if out, err := cmd.CombinedOutput(); err != nil && mustSucceed {
+		g.err = fmt.Errorf("git %v: %v: %s", args, err, out)
+	}
+}
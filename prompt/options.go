@@ -0,0 +1,123 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+//EnvOptionPrefix is the prefix of the environment variables setting plugin options.
+//GOPROMPT_OPT_PATH_FULLPATH sets path.fullpath
+const EnvOptionPrefix = "GOPROMPT_OPT_"
+
+//OptionLayer is a set of options coming from a single source: a template defaults,
+//a config file, the environment or the command line
+type OptionLayer struct {
+	Source  string
+	Options map[string]interface{}
+}
+
+//MergeOptions merges the option layers key by key, each layer overriding the previous ones
+func MergeOptions(layers ...OptionLayer) map[string]interface{} {
+	var options map[string]interface{}
+	for _, l := range layers {
+		if l.Options == nil {
+			continue
+		}
+		if options == nil {
+			options = make(map[string]interface{})
+		}
+		for k, v := range l.Options {
+			options[k] = v
+		}
+	}
+	return options
+}
+
+//EffectiveOption is the value of an option after merging all the layers, and where it comes from
+type EffectiveOption struct {
+	Value  interface{}
+	Source string
+}
+
+//EffectiveOptions returns every declared option with its final value and source after
+//merging the layers. Options not set by any layer get the plugin default
+func EffectiveOptions(layers ...OptionLayer) map[string]EffectiveOption {
+	effective := make(map[string]EffectiveOption)
+	for name, o := range declaredOptions() {
+		effective[name] = EffectiveOption{Value: o.Default, Source: "default"}
+	}
+	for _, l := range layers {
+		for k, v := range l.Options {
+			effective[k] = EffectiveOption{Value: v, Source: l.Source}
+		}
+	}
+	return effective
+}
+
+//ParseOption parses a key=value string (as given in the command line) for a declared option
+func ParseOption(s string) (string, interface{}, error) {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 {
+		return "", nil, fmt.Errorf("invalid option %s, expecting key=value", s)
+	}
+	v, err := parseOptionValue(kv[0], kv[1])
+	return kv[0], v, err
+}
+
+//EnvOptions returns the options set on environment variables named EnvOptionPrefix
+//followed by the option name in upper case with dots replaced by underscores
+func EnvOptions() (OptionLayer, []error) {
+	declared := declaredOptions()
+	byEnv := make(map[string]string, len(declared))
+	for name := range declared {
+		byEnv[EnvOptionPrefix+strings.ToUpper(strings.Replace(name, ".", "_", -1))] = name
+	}
+
+	layer := OptionLayer{Source: "environment"}
+	var errs []error
+	environ := os.Environ()
+	sort.Strings(environ)
+	for _, e := range environ {
+		if !strings.HasPrefix(e, EnvOptionPrefix) {
+			continue
+		}
+		kv := strings.SplitN(e, "=", 2)
+		name, ok := byEnv[kv[0]]
+		if !ok && strings.HasSuffix(kv[0], "_ON_ERROR") {
+			name, ok = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(kv[0], EnvOptionPrefix), "_ON_ERROR"))+".on_error", true
+		}
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown option", kv[0]))
+			continue
+		}
+		v, err := parseOptionValue(name, kv[1])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", kv[0], err))
+			continue
+		}
+		if layer.Options == nil {
+			layer.Options = make(map[string]interface{})
+		}
+		layer.Options[name] = v
+	}
+	return layer, errs
+}
+
+func parseOptionValue(name, value string) (interface{}, error) {
+	o, ok := declaredOptions()[name]
+	if !ok {
+		//the segments and external plugins are not known yet, any plugin can set its error policy
+		p, isPolicy := errorPolicyPlugin(name)
+		if !isPolicy {
+			return nil, fmt.Errorf("unknown option %s", name)
+		}
+		o = errorPolicyOption(p)
+	}
+	v, err := o.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("option %s: %v", name, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,74 @@
+package prompt
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOptionLayers(t *testing.T) {
+	os.Setenv("GOPROMPT_OPT_PATH_FULLPATH", "2")
+	os.Setenv("GOPROMPT_OPT_GIT_FETCH_INTERVAL", "1m")
+	os.Setenv("GOPROMPT_OPT_UNKNOWN", "x")
+	defer os.Unsetenv("GOPROMPT_OPT_PATH_FULLPATH")
+	defer os.Unsetenv("GOPROMPT_OPT_GIT_FETCH_INTERVAL")
+	defer os.Unsetenv("GOPROMPT_OPT_UNKNOWN")
+
+	env, errs := EnvOptions()
+	if len(errs) != 1 || errs[0].Error() != "GOPROMPT_OPT_UNKNOWN: unknown option" {
+		t.Errorf("expecting unknown option error, got %v", errs)
+	}
+
+	key, value, err := ParseOption("path.fullpath=3")
+	if err != nil {
+		t.Fatalf("unable to parse option: %v", err)
+	}
+	cli := OptionLayer{Source: "command line", Options: map[string]interface{}{key: value}}
+
+	template := OptionLayer{Source: "template", Options: map[string]interface{}{"path.fullpath": float64(1)}}
+	config := OptionLayer{Source: "config", Options: map[string]interface{}{"path.fullpath": float64(0), "git.fetch_interval": float64(10)}}
+
+	tt := []struct {
+		name   string
+		layers []OptionLayer
+		expect map[string]interface{}
+	}{
+		{
+			name:   "config over template",
+			layers: []OptionLayer{template, config},
+			expect: map[string]interface{}{"path.fullpath": float64(0), "git.fetch_interval": float64(10)},
+		},
+		{
+			name:   "env over config",
+			layers: []OptionLayer{template, config, env},
+			expect: map[string]interface{}{"path.fullpath": 2, "git.fetch_interval": time.Minute},
+		},
+		{
+			name:   "command line over env",
+			layers: []OptionLayer{template, config, env, cli},
+			expect: map[string]interface{}{"path.fullpath": 3, "git.fetch_interval": time.Minute},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			options := MergeOptions(tc.layers...)
+			if !reflect.DeepEqual(options, tc.expect) {
+				t.Errorf("expecting %v got %v", tc.expect, options)
+			}
+		})
+	}
+
+	if _, _, err := ParseOption("path.fullpath"); err == nil {
+		t.Errorf("expecting error parsing option without value")
+	}
+	if _, _, err := ParseOption("path.fullpath=x"); err == nil || err.Error() != "option path.fullpath: expecting an integer, got x" {
+		t.Errorf("expecting error parsing invalid value, got %v", err)
+	}
+	if key, value, err := ParseOption("weather.on_error=fail"); err != nil || key != "weather.on_error" || value != OnErrorFail {
+		t.Errorf("expecting the error policy of an external plugin, got %s=%v (%v)", key, value, err)
+	}
+	if _, _, err := ParseOption("weather.on_error=explode"); err == nil {
+		t.Errorf("expecting error parsing invalid error policy")
+	}
+}
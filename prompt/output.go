@@ -0,0 +1,68 @@
+package prompt
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+//DataPlugin is implemented by the plugins exposing their state as structured data,
+//included in the json output
+type DataPlugin interface {
+	Data() map[string]interface{}
+}
+
+//loadedPlugin is a plugin loaded by the template, with its output
+type loadedPlugin struct {
+	name   string
+	plugin Plugin
+	output string
+	role   theme.Role
+	err    error
+	//duration is the time spent loading the plugin, cached whether it reused a cached value
+	duration time.Duration
+	cached   bool
+}
+
+//Segment is the output of a plugin loaded by the template, as emitted in the json output
+type Segment struct {
+	Name  string                 `json:"name"`
+	Text  string                 `json:"text"`
+	Role  theme.Role             `json:"role,omitempty"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+	Error string                 `json:"error,omitempty"`
+}
+
+//Output is the json output: the rendered prompt and the output of every plugin it loaded
+type Output struct {
+	Prompt   string    `json:"prompt"`
+	Segments []Segment `json:"segments"`
+}
+
+//CompileOutput processes the template like Compile, returning the prompt along with
+//the text and data of each loaded plugin
+func (pr *Prompt) CompileOutput(tmpl string) Output {
+	prompt := pr.Compile(tmpl)
+	out := Output{Prompt: prompt, Segments: make([]Segment, 0, len(pr.loaded))}
+	for _, l := range pr.loaded {
+		s := Segment{Name: l.name, Text: l.output, Role: l.role}
+		if l.err != nil {
+			s.Error = l.err.Error()
+		} else if d, ok := l.plugin.(DataPlugin); ok {
+			s.Data = d.Data()
+		}
+		out.Segments = append(out.Segments, s)
+	}
+	return out
+}
+
+//CompileJSON processes the template and returns its Output as json
+func (pr *Prompt) CompileJSON(tmpl string) string {
+	data, err := json.Marshal(pr.CompileOutput(tmpl))
+	if err != nil {
+		log.Fatalf("unable to marshal output: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,48 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+type fakeDataPlugin struct {
+	fakePlugin
+}
+
+func (f fakeDataPlugin) Data() map[string]interface{} {
+	return map[string]interface{}{"value": f.output}
+}
+
+func TestCompileOutput(t *testing.T) {
+	th := theme.Default()
+	pr := Prompt{
+		cache: &Cache{},
+		plugins: map[string]Plugin{
+			"a":     fakePlugin{name: "a", output: "A", role: "a"},
+			"b":     fakeDataPlugin{fakePlugin{name: "b", output: "B", role: "b"}},
+			"empty": fakePlugin{name: "empty"},
+		},
+		format: th.Formatter(theme.Renderer{Target: theme.Plain}),
+		theme:  th,
+	}
+
+	out := pr.CompileOutput(`{{load "a"|suffix ">"}}{{load "empty"}}{{load "b"}}$`)
+	expected := Output{
+		Prompt: "A>B$",
+		Segments: []Segment{
+			{Name: "a", Text: "A", Role: "a"},
+			{Name: "empty"},
+			{Name: "b", Text: "B", Role: "b", Data: map[string]interface{}{"value": "B"}},
+		},
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Errorf("Expected %+v\nGot      %+v", expected, out)
+	}
+
+	json := pr.CompileJSON(`{{load "b"}}`)
+	if json != `{"prompt":"B","segments":[{"name":"b","text":"B","role":"b","data":{"value":"B"}}]}` {
+		t.Errorf("unexpected json output %s", json)
+	}
+}
@@ -1,17 +1,19 @@
 package plugin
 
 import (
-	"os"
 	"strconv"
 	"time"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // Aws is the plugin struct
 type Aws struct {
 	role   string
 	expire time.Time
+	now    time.Time
+	icons  icon.Set
 }
 
 // Name returns the plugin name
@@ -20,32 +22,47 @@ func (Aws) Name() string {
 }
 
 // Help returns help information about this plugin
-func (Aws) Help() (description string, options map[string]string) {
+func (Aws) Help() (description string, options []Option) {
 	description = "This plugins show aws information(it needs AWS_ROLE + AWS_SESSION_EXPIRE non standard environment variables"
 	return
 }
 
 // Load is the load function of the plugin
-func (a *Aws) Load(Prompter) error {
-	a.role = os.Getenv("AWS_ROLE")
-	iExpire, _ := strconv.ParseInt(os.Getenv("AWS_SESSION_EXPIRE"), 10, 0)
+func (a *Aws) Load(pr Prompter) error {
+	*a = Aws{}
+	a.icons = icons(pr)
+	a.role = getenv(pr, "AWS_ROLE")
+	iExpire, _ := strconv.ParseInt(getenv(pr, "AWS_SESSION_EXPIRE"), 10, 0)
 	a.expire = time.Unix(iExpire, int64(0))
+	a.now = now(pr)
 	return nil
 }
 
 // Get returns the string to use in the prompt
-func (a Aws) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (a Aws) Get(format theme.Formatter) (string, theme.Role) {
 	if a.role != "" {
-		t := termcolor.FgGreen
-		d := time.Until(a.expire).Seconds()
+		var role theme.Role = "aws.valid"
+		d := a.expire.Sub(a.now).Seconds()
 		if d < 0 {
-			t = termcolor.FgRed
+			role = "aws.expired"
 		} else if d < 600 {
-			t = termcolor.FgYellow
+			role = "aws.expiring"
 		} else if d < 1800 {
-			t = termcolor.FgBlue
+			role = "aws.warning"
 		}
-		return format(a.role, t), nil
+		return format(a.icons.Prefix("aws")+a.role, role), ""
+	}
+	return "", ""
+}
+
+// Data returns the plugin state as structured data
+func (a Aws) Data() map[string]interface{} {
+	if a.role == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"role":    a.role,
+		"expire":  a.expire,
+		"expired": a.now.After(a.expire),
 	}
-	return "", nil
 }
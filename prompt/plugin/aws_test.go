@@ -1,22 +1,23 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 	"time"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestAws(t *testing.T) {
-
-	os.Setenv("AWS_ROLE", "test:xx-yy-zz")
-	os.Setenv("AWS_SESSION_EXPIRE", "1506345326")
+	t.Parallel()
+	pr := &plugintest.Prompter{
+		Env:   map[string]string{"AWS_ROLE": "test:xx-yy-zz", "AWS_SESSION_EXPIRE": "1506345326"},
+		Clock: time.Unix(1506345326+60, 0),
+	}
 	expectedPrompt := "\\[\\033[0m\\]\\[\\033[31m\\]test:xx-yy-zz\\[\\033[0m\\]"
 	expectedRole := "test:xx-yy-zz"
 
 	a := &Aws{}
-	a.Load(nil)
+	a.Load(pr)
 
 	if a.role != expectedRole {
 		t.Errorf("expected role %s, got %s", expectedRole, a.role)
@@ -25,7 +26,7 @@ func TestAws(t *testing.T) {
 	if !(time.Unix(1506345326, int64(0)).Equal(a.expire)) {
 		t.Errorf("AWS expire time error. expected %d, got %d", 1506345326, a.expire.Unix())
 	}
-	output, _ := a.Get(termcolor.EscapedFormat)
+	output, _ := a.Get(bashFormat)
 	if output != expectedPrompt {
 		t.Errorf("Expected %s\nGot      %s", expectedPrompt, output)
 	}
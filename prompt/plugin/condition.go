@@ -0,0 +1,101 @@
+package plugin
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Condition is the "when" condition of a configured segment, which is shown only if it
+// matches the current directory. It is a list of checks joined with "&&":
+//
+//	exists <path>    path exists, relative to the current directory
+//	dir <pattern>    the current directory, or any of its parents, matches the glob pattern
+//	env <name>       the environment variable name is set and not empty
+//
+// Any check can be negated with a leading "!"
+type Condition struct {
+	checks []check
+}
+
+type check struct {
+	kind   string
+	arg    string
+	negate bool
+}
+
+// ParseCondition parses a condition. The empty condition always matches
+func ParseCondition(s string) (Condition, error) {
+	var c Condition
+	if strings.TrimSpace(s) == "" {
+		return c, nil
+	}
+	for _, part := range strings.Split(s, "&&") {
+		part = strings.TrimSpace(part)
+		var ch check
+		if strings.HasPrefix(part, "!") {
+			ch.negate = true
+			part = strings.TrimSpace(part[1:])
+		}
+		fields := strings.SplitN(part, " ", 2)
+		if len(fields) != 2 || strings.TrimSpace(fields[1]) == "" {
+			return c, fmt.Errorf("invalid condition %q, expecting exists <path>, dir <pattern> or env <name>", part)
+		}
+		ch.kind, ch.arg = fields[0], strings.TrimSpace(fields[1])
+		switch ch.kind {
+		case "exists", "env":
+		case "dir":
+			if _, err := filepath.Match(ch.arg, ""); err != nil {
+				return c, fmt.Errorf("invalid pattern %q: %v", ch.arg, err)
+			}
+		default:
+			return c, fmt.Errorf("unknown condition %q, expecting exists, dir or env", ch.kind)
+		}
+		c.checks = append(c.checks, ch)
+	}
+	return c, nil
+}
+
+// Match returns true if every check matches in the directory cwd, with the environment
+// and the filesystem seen by pr
+func (c Condition) Match(pr Prompter, cwd string) bool {
+	for _, ch := range c.checks {
+		if ch.match(pr, cwd) == ch.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (ch check) match(pr Prompter, cwd string) bool {
+	switch ch.kind {
+	case "exists":
+		path := expandHome(pr, ch.arg)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cwd, path)
+		}
+		_, err := stat(pr, path)
+		return err == nil
+	case "dir":
+		pattern := expandHome(pr, ch.arg)
+		for dir := filepath.Clean(cwd); ; dir = filepath.Dir(dir) {
+			if ok, _ := filepath.Match(pattern, dir); ok {
+				return true
+			}
+			if dir == filepath.Dir(dir) {
+				return false
+			}
+		}
+	case "env":
+		return getenv(pr, ch.arg) != ""
+	}
+	return false
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(pr Prompter, p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return getenv(pr, "HOME") + p[1:]
+	}
+	return p
+}
@@ -0,0 +1,55 @@
+package plugin
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
+)
+
+func TestCondition(t *testing.T) {
+	t.Parallel()
+	cwd := "/work/project"
+	pr := &plugintest.Prompter{
+		Env:   map[string]string{"GOPROMPT_TEST_VAR": "value", "HOME": "/home/test"},
+		Dir:   cwd,
+		Files: fstest.MapFS{"work/project/.terraform": &fstest.MapFile{Mode: fs.ModeDir}, "home/test/.vault": &fstest.MapFile{}},
+	}
+
+	testCases := []struct {
+		when     string
+		expected bool
+		err      bool
+	}{
+		{when: "", expected: true},
+		{when: "exists .terraform", expected: true},
+		{when: "exists go.mod", expected: false},
+		{when: "!exists go.mod", expected: true},
+		{when: "exists ~/.vault", expected: true},
+		{when: "dir /work/*", expected: true},
+		{when: "dir /work", expected: true},
+		{when: "dir /home/*", expected: false},
+		{when: "env GOPROMPT_TEST_VAR && exists .terraform", expected: true},
+		{when: "env GOPROMPT_TEST_VAR && env GOPROMPT_TEST_MISSING", expected: false},
+		{when: "file x", err: true},
+		{when: "exists", err: true},
+		{when: "dir [", err: true},
+	}
+	for _, tc := range testCases {
+		c, err := ParseCondition(tc.when)
+		if tc.err {
+			if err == nil {
+				t.Errorf("%q: expected error", tc.when)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tc.when, err)
+			continue
+		}
+		if c.Match(pr, cwd) != tc.expected {
+			t.Errorf("%q: expected %v", tc.when, tc.expected)
+		}
+	}
+}
@@ -2,9 +2,8 @@ package plugin
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // ExitUserChar is the plugin struct
@@ -19,18 +18,19 @@ func (ExitUserChar) Name() string {
 }
 
 // Help returns help information about this plugin
-func (ExitUserChar) Help() (description string, options map[string]string) {
+func (ExitUserChar) Help() (description string, options []Option) {
 	description = "This plugins show the typical final char for the prompt (# is the user is root, $ otherwise) but it will be red if the last command exited with rc!=0"
 	return
 }
 
 // Load is the load function of the plugin
-func (euc *ExitUserChar) Load(Prompter) error {
-	euc.user = os.Getenv("USER")
+func (euc *ExitUserChar) Load(pr Prompter) error {
+	*euc = ExitUserChar{}
+	euc.user = getenv(pr, "USER")
 	if euc.user == "" {
 		return fmt.Errorf("unable to get USER")
 	}
-	euc.lastrc = os.Getenv("LAST_COMMAND_RC")
+	euc.lastrc = getenv(pr, "LAST_COMMAND_RC")
 	if euc.lastrc == "" {
 		return fmt.Errorf("unable to get LAST_COMMAND_RC")
 	}
@@ -39,13 +39,21 @@ func (euc *ExitUserChar) Load(Prompter) error {
 }
 
 // Get returns the string to use in the prompt
-func (euc *ExitUserChar) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (euc *ExitUserChar) Get(format theme.Formatter) (string, theme.Role) {
 	char := "$"
 	if euc.user == "root" {
 		char = "#"
 	}
 	if euc.lastrc == "0" {
-		return char, nil
+		return char, ""
+	}
+	return format(char, "status.error"), "status.error"
+}
+
+// Data returns the plugin state as structured data
+func (euc *ExitUserChar) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"root":  euc.user == "root",
+		"error": euc.lastrc != "0",
 	}
-	return format(char, termcolor.FgHiRed), []termcolor.Mode{termcolor.FgHiRed}
 }
@@ -1,10 +1,9 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestExitUserChar(t *testing.T) {
@@ -42,12 +41,13 @@ func TestExitUserChar(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			os.Setenv("LAST_COMMAND_RC", tc.lastrc)
-			os.Setenv("USER", tc.user)
+			t.Parallel()
+			prompter := &plugintest.Prompter{Env: map[string]string{"LAST_COMMAND_RC": tc.lastrc, "USER": tc.user}}
 			euc := &ExitUserChar{}
-			euc.Load(nil)
-			pr, _ := euc.Get(termcolor.EscapedFormat)
+			euc.Load(prompter)
+			pr, _ := euc.Get(bashFormat)
 			if pr != tc.expectedPrompt {
 				t.Fatalf("Generated prompt do not match:\n%s\n%s", pr, tc.expectedPrompt)
 			}
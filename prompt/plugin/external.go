@@ -0,0 +1,219 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+// ExternalPrefix is the prefix of the external plugin executables looked up on PATH:
+// goprompt-plugin-<name> is the plugin called name
+const ExternalPrefix = "goprompt-plugin-"
+
+// externalEnv are the environment variables always sent to the external plugins
+var externalEnv = []string{"HOME", "USER", "PATH", "PWD", "SHELL", "TERM", "LANG"}
+
+// ExternalRequest is the json written to the standard input of an external plugin
+type ExternalRequest struct {
+	Name    string                 `json:"name"`
+	Cwd     string                 `json:"cwd"`
+	Env     map[string]string      `json:"env"`
+	Options map[string]interface{} `json:"options"`
+}
+
+// ExternalPart is a piece of the output of an external plugin styled with its own role
+type ExternalPart struct {
+	Text string     `json:"text"`
+	Role theme.Role `json:"role,omitempty"`
+}
+
+// ExternalResponse is the json an external plugin writes to its standard output. The
+// output is Text styled with Role, or the Parts one after another if there are any.
+// If CacheTTL (seconds) is set the response is reused for that long for the same request
+type ExternalResponse struct {
+	Text     string                 `json:"text"`
+	Role     theme.Role             `json:"role,omitempty"`
+	Parts    []ExternalPart         `json:"parts,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	CacheTTL float64                `json:"cache_ttl,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// External is a plugin implemented by an external command
+type External struct {
+	name     string
+	command  string
+	options  map[string]interface{}
+	response ExternalResponse
+}
+
+// NewExternal returns the external plugin called name running command, with the
+// options set for it (the keys prefixed with "<name>.")
+func NewExternal(name, command string, options map[string]interface{}) *External {
+	return &External{name: name, command: command, options: options}
+}
+
+// Name returns the plugin name
+func (e *External) Name() string {
+	return e.name
+}
+
+// Help returns help information about this plugin
+func (e *External) Help() (description string, options []Option) {
+	description = "External plugin running " + e.command
+	options = []Option{
+		{
+			Name:        e.name + ".timeout",
+			Type:        OptionDuration,
+			Default:     500 * time.Millisecond,
+			Description: "maximum time the command can take",
+		},
+		{
+			Name:        e.name + ".env",
+			Type:        OptionString,
+			Default:     "",
+			Description: "comma separated environment variables sent to the command, besides " + strings.Join(externalEnv, ","),
+		},
+	}
+	return
+}
+
+// Load is the load function of the plugin
+func (e *External) Load(pr Prompter) error {
+	e.response = ExternalResponse{}
+	req, err := e.request(pr)
+	if err != nil {
+		return err
+	}
+	in, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("unable to marshal request: %v", err)
+	}
+	sum := sha1.Sum(in)
+	key := "external-" + e.name + "-" + hex.EncodeToString(sum[:])
+
+	cached, ok := cachedValue(pr, key)
+	out := []byte(cached)
+	if !ok {
+		out, err = e.run(in, req)
+		if err != nil {
+			return err
+		}
+	}
+	var resp ExternalResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		return fmt.Errorf("unable to unmarshal %s response: %v", e.command, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%s: %s", e.command, resp.Error)
+	}
+	if !ok && resp.CacheTTL > 0 {
+		cacheValue(pr, key, string(out), time.Duration(resp.CacheTTL*float64(time.Second)))
+	}
+	e.response = resp
+	return nil
+}
+
+// request returns the request sent to the command
+func (e *External) request(pr Prompter) (ExternalRequest, error) {
+	cwd, err := getwd(pr)
+	if err != nil {
+		return ExternalRequest{}, fmt.Errorf("unable to get current directory: %v", err)
+	}
+	req := ExternalRequest{Name: e.name, Cwd: cwd, Env: make(map[string]string), Options: make(map[string]interface{})}
+	names := externalEnv
+	if extra, ok := e.option("env").(string); ok && extra != "" {
+		names = append(append([]string{}, names...), strings.Split(extra, ",")...)
+	}
+	for _, n := range names {
+		if v := getenv(pr, strings.TrimSpace(n)); v != "" {
+			req.Env[strings.TrimSpace(n)] = v
+		}
+	}
+	for k, v := range e.options {
+		req.Options[strings.TrimPrefix(k, e.name+".")] = v
+	}
+	return req, nil
+}
+
+// run runs the command in the request directory with in, the request, on its standard input
+// and returns its output. The command environment is the one in the request
+func (e *External) run(in []byte, req ExternalRequest) ([]byte, error) {
+	timeout, _ := e.option("timeout").(time.Duration)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, e.command)
+	cmd.Dir = req.Cwd
+	cmd.Env = make([]string, 0, len(req.Env))
+	for k, v := range req.Env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	sort.Strings(cmd.Env)
+	cmd.Stdin = bytes.NewReader(in)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("%s timed out after %v", e.command, timeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to run %s: %v: %s", e.command, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// option returns the option called name (without the plugin prefix) converted to its type,
+// or its default value if it is not set or it is invalid
+func (e *External) option(name string) interface{} {
+	_, options := e.Help()
+	for _, o := range options {
+		if o.Name != e.name+"."+name {
+			continue
+		}
+		if v, ok := e.options[o.Name]; ok {
+			if c, err := o.Convert(v); err == nil {
+				return c
+			}
+		}
+		return o.Default
+	}
+	return nil
+}
+
+// Get returns the string to use in the prompt
+func (e *External) Get(format theme.Formatter) (string, theme.Role) {
+	role := e.response.Role
+	if role == "" {
+		role = theme.Role(e.name)
+	}
+	if len(e.response.Parts) == 0 {
+		if e.response.Text == "" {
+			return "", role
+		}
+		return format(e.response.Text, role), role
+	}
+	var b strings.Builder
+	for _, p := range e.response.Parts {
+		r := p.Role
+		if r == "" {
+			r = role
+		}
+		b.WriteString(format(p.Text, r))
+	}
+	return b.String(), role
+}
+
+// Data returns the plugin state as structured data
+func (e *External) Data() map[string]interface{} {
+	return e.response.Data
+}
@@ -0,0 +1,149 @@
+package plugin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+// writeScript writes an executable shell script to dir and returns its path
+func writeScript(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("unable to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestExternal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-external")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	testCases := []struct {
+		name           string
+		script         string
+		options        map[string]interface{}
+		expectedPrompt string
+		expectedData   map[string]interface{}
+		expectedErr    string
+	}{
+		{
+			name:           "text",
+			script:         `echo '{"text": "sunny", "role": "red", "data": {"temp": 21}}'`,
+			expectedPrompt: "\\[\\033[0m\\]\\[\\033[31m\\]sunny\\[\\033[0m\\]",
+			expectedData:   map[string]interface{}{"temp": float64(21)},
+		},
+		{
+			name:           "parts",
+			script:         `echo '{"parts": [{"text": "a", "role": "bold"}, {"text": "b"}], "role": "red"}'`,
+			expectedPrompt: "\\[\\033[0m\\]\\[\\033[1m\\]a\\[\\033[0m\\]\\[\\033[0m\\]\\[\\033[31m\\]b\\[\\033[0m\\]",
+		},
+		{
+			name:        "plugin error",
+			script:      `echo '{"error": "no network"}'`,
+			expectedErr: "no network",
+		},
+		{
+			name:        "exit status",
+			script:      "echo broken >&2\nexit 1",
+			expectedErr: "broken",
+		},
+		{
+			name:        "invalid response",
+			script:      `echo 'sunny'`,
+			expectedErr: "unable to unmarshal",
+		},
+		{
+			name:        "timeout",
+			script:      "exec sleep 2",
+			options:     map[string]interface{}{"test.timeout": "50ms"},
+			expectedErr: "timed out after 50ms",
+		},
+	}
+	for _, tc := range testCases {
+		command := writeScript(t, dir, strings.Replace(tc.name, " ", "_", -1), tc.script)
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			e := NewExternal("test", command, tc.options)
+			err := e.Load(&plugintest.Prompter{Dir: dir})
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if output, _ := e.Get(bashFormat); output != tc.expectedPrompt {
+				t.Errorf("Expected %q\nGot      %q", tc.expectedPrompt, output)
+			}
+			if tc.expectedData != nil && e.Data()["temp"] != tc.expectedData["temp"] {
+				t.Errorf("Expected data %v, got %v", tc.expectedData, e.Data())
+			}
+		})
+	}
+}
+
+func TestExternalRequestAndCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-external")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	requestFile, envFile := filepath.Join(dir, "request"), filepath.Join(dir, "env")
+	command := writeScript(t, dir, "weather", "cat > "+requestFile+"\necho \"$GOPROMPT_TEST_VAR|$HOME\" > "+envFile+"\necho '{\"text\": \"sunny\", \"cache_ttl\": 60}'\n")
+	start := time.Now()
+	pr := &plugintest.Prompter{Env: map[string]string{"GOPROMPT_TEST_VAR": "value"}, Dir: dir, Clock: start}
+	e := NewExternal("weather", command, map[string]interface{}{"weather.city": "Madrid", "weather.env": "GOPROMPT_TEST_VAR"})
+	if err := e.Load(pr); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	data, err := ioutil.ReadFile(requestFile)
+	if err != nil {
+		t.Fatalf("unable to read the request: %v", err)
+	}
+	var req ExternalRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("unable to unmarshal the request %s: %v", data, err)
+	}
+	if req.Name != "weather" || req.Cwd != dir || req.Options["city"] != "Madrid" || req.Env["GOPROMPT_TEST_VAR"] != "value" {
+		t.Errorf("unexpected request %s", data)
+	}
+	//the command sees the environment of the prompter, not the process one
+	if env, _ := ioutil.ReadFile(envFile); string(env) != "value|\n" {
+		t.Errorf("expected the request environment only, got %q", env)
+	}
+
+	//the cached response is used while it does not expire, without running the command
+	os.Remove(requestFile)
+	if err := e.Load(pr); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := os.Stat(requestFile); !os.IsNotExist(err) {
+		t.Errorf("expected the cached response to be used")
+	}
+	if output, _ := e.Get(theme.Default().Formatter(theme.Renderer{Target: theme.Plain})); output != "sunny" {
+		t.Errorf("expected cached output sunny, got %q", output)
+	}
+	pr.Clock = start.Add(61 * time.Second)
+	if err := e.Load(pr); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := os.Stat(requestFile); err != nil {
+		t.Errorf("expected the command to run again once expired")
+	}
+}
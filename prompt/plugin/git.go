@@ -1,43 +1,44 @@
 package plugin
 
 import (
-	"bytes"
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"syscall"
 	"time"
 
 	git2go "github.com/jeffwelling/git2go/v37"
-	"github.com/josledp/termcolor"
-)
-
-const (
-	sDownArrow = "↓"
-	sUpArrow   = "↑"
-	sThreeDots = "…"
-	sDot       = "●"
-	sCheck     = "✔"
-	sFlag      = "⚑"
-	sAsterisk  = "⭑"
-	sCross     = "✖"
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // Git is the plugin struct
 type Git struct {
-	conflicted    int
-	detached      bool
-	changed       int
-	staged        int
-	untracked     int
-	commitsAhead  int
-	commitsBehind int
-	stashed       int
-	branch        string
-	hasUpstream   bool
+	conflicted       int
+	detached         bool
+	changed          int
+	staged           int
+	untracked        int
+	submodules       int
+	commitsAhead     int
+	commitsBehind    int
+	stashed          int
+	branch           string
+	hasUpstream      bool
+	bare             bool
+	worktree         string
+	superproject     string
+	submoduleChanged bool
+	tag              string
+	describe         string
+	commit           string
+	subject          string
+	author           string
+	age              time.Duration
+	icons            icon.Set
 }
 
 // Name returns the plugin name
@@ -46,14 +47,48 @@ func (Git) Name() string {
 }
 
 // Help returns help information about this plugin
-func (Git) Help() (description string, options map[string]string) {
+func (Git) Help() (description string, options []Option) {
 	description = "This plugins show git information in the current git repo"
+	options = []Option{
+		{
+			Name:        "git.fetch_interval",
+			Type:        OptionDuration,
+			Default:     300 * time.Second,
+			Description: "minimum time between the background fetches of the upstream branch (0 disables fetching)",
+		},
+		{
+			Name:        "git.tag",
+			Type:        OptionBool,
+			Default:     false,
+			Description: "show the tag HEAD is at, if any",
+		},
+		{
+			Name:        "git.describe",
+			Type:        OptionBool,
+			Default:     false,
+			Description: "show the nearest tag, the commits since it and the commit id, as git describe --tags",
+		},
+		{
+			Name:        "git.last_commit",
+			Type:        OptionBool,
+			Default:     false,
+			Description: "show the id and the age of the last commit (its subject and author are in the json output)",
+		},
+	}
 	return
 }
 
 // Load is the load function of the plugin
 func (g *Git) Load(pr Prompter) error {
-	gitpath, err := git2go.Discover(".", false, []string{"/"})
+	//nothing is kept from a previous load, the counters start again
+	*g = Git{}
+	g.icons = icons(pr)
+	//libgit2 reads the repository from the real filesystem, only the directory comes from pr
+	start := "."
+	if cwd, err := getwd(pr); err == nil {
+		start = cwd
+	}
+	gitpath, err := git2go.Discover(start, false, []string{"/"})
 	if err == nil {
 		repository, err := git2go.OpenRepository(gitpath)
 		if err != nil {
@@ -61,76 +96,15 @@ func (g *Git) Load(pr Prompter) error {
 		}
 		defer repository.Free()
 
-		//Get current tracked & untracked files status
-		statusOpts := git2go.StatusOptions{
-			Flags: git2go.StatusOptIncludeUntracked | git2go.StatusOptRenamesHeadToIndex,
-		}
-		repostate, err := repository.StatusList(&statusOpts)
-		if err != nil {
-			return fmt.Errorf("error getting repository status at %s: %v", gitpath, err)
-		}
-		defer repostate.Free()
-		n, err := repostate.EntryCount()
-		if err != nil {
-			return fmt.Errorf("error getting repository status entry count at %s: %v", gitpath, err)
-		}
-		for i := 0; i < n; i++ {
-			entry, _ := repostate.ByIndex(i)
-			got := false
-			if entry.Status&git2go.StatusCurrent > 0 {
-				got = true
-			}
-			if entry.Status&git2go.StatusIndexNew > 0 {
-				g.staged++
-				got = true
-			}
-			if entry.Status&git2go.StatusIndexModified > 0 {
-				g.staged++
-				got = true
-			}
-			if entry.Status&git2go.StatusIndexDeleted > 0 {
-				g.staged++
-				got = true
-			}
-			if entry.Status&git2go.StatusIndexRenamed > 0 {
-				g.staged++
-				got = true
-			}
-			if entry.Status&git2go.StatusIndexTypeChange > 0 {
-				g.staged++
-				got = true
-			}
-			if entry.Status&git2go.StatusWtNew > 0 {
-				g.untracked++
-				got = true
-			}
-			if entry.Status&git2go.StatusWtModified > 0 {
-				g.changed++
-				got = true
-			}
-			if entry.Status&git2go.StatusWtDeleted > 0 {
-				g.changed++
-				got = true
-			}
-			if entry.Status&git2go.StatusWtTypeChange > 0 {
-				g.changed++
-				got = true
-			}
-			if entry.Status&git2go.StatusWtRenamed > 0 {
-				g.changed++
-				got = true
-			}
-			if entry.Status&git2go.StatusIgnored > 0 {
-				got = true
-			}
-			if entry.Status&git2go.StatusConflicted > 0 {
-				g.conflicted++
-				got = true
-			}
-			if !got {
-				log.Println("Git plugin. Unknown: ", entry.Status)
+		//a bare repository has no workdir to get the status of
+		g.bare = repository.IsBare()
+		if !g.bare {
+			if err := g.loadStatus(repository); err != nil {
+				return err
 			}
 		}
+		g.worktree = worktreeName(repository)
+
 		//Get current branch name
 		localRef, err := repository.Head()
 		if err != nil {
@@ -145,6 +119,8 @@ func (g *Git) Load(pr Prompter) error {
 			return fmt.Errorf("error getting local branch: %v", err)
 		}
 
+		g.superproject, g.submoduleChanged = superproject(repository.Workdir(), localRef.Target())
+
 		if isHead, _ := localBranch.IsHead(); isHead {
 			g.branch = localRef.Shorthand()
 		} else {
@@ -158,18 +134,24 @@ func (g *Git) Load(pr Prompter) error {
 			defer remoteRef.Free()
 
 			g.hasUpstream = true
-			pwd, err := os.Getwd()
-			if err == nil {
+			fetchInterval := 300 * time.Second
+			if value, ok := pr.GetOption(g.Name() + ".fetch_interval"); ok {
+				if v, ok := value.(time.Duration); ok {
+					fetchInterval = v
+				}
+			}
+			pwd, err := getwd(pr)
+			if err == nil && fetchInterval > 0 {
 				key := fmt.Sprintf("git-%s-fetch", pwd)
 				last, ok := pr.GetCache(key)
 				var lastTime time.Time
-				if last != nil {
-					lastTime, err = time.Parse(time.RFC3339, last.(string))
+				if last, isString := last.(string); isString {
+					lastTime, err = time.Parse(time.RFC3339, last)
 					if err != nil {
 						log.Printf("Error loading git last fetch time: %v", err)
 					}
 				}
-				if !ok || time.Since(lastTime) > 300*time.Second {
+				if !ok || now(pr).Sub(lastTime) > fetchInterval {
 					pa := syscall.ProcAttr{}
 					pa.Env = os.Environ()
 					pa.Dir = pwd
@@ -182,7 +164,7 @@ func (g *Git) Load(pr Prompter) error {
 							//Silently fail?
 							log.Printf("Error fetching: %v", err)
 						} else {
-							pr.Cache(key, time.Now())
+							pr.Cache(key, now(pr).Format(time.RFC3339))
 						}
 					}
 				}
@@ -195,78 +177,358 @@ func (g *Git) Load(pr Prompter) error {
 				}
 			}
 		}
-		if fstash, err := os.Open(gitpath + "/logs/refs/stash"); err == nil {
-			defer fstash.Close()
-			g.stashed, err = lineCounter(fstash)
-			if err != nil {
-				return fmt.Errorf("unable to count stashes:%v", err)
+		g.stashed, err = stashCount(repository)
+		if err != nil {
+			return fmt.Errorf("unable to count stashes: %v", err)
+		}
+		if err := g.loadHead(pr, repository, localRef.Target()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enabled returns whether the bool option name is set
+func enabled(pr Prompter, name string) bool {
+	value, _ := pr.GetOption(name)
+	v, _ := value.(bool)
+	return v
+}
+
+// loadHead reads the information about the commit at HEAD the options enable, so it is only
+// looked up when it is shown
+func (g *Git) loadHead(pr Prompter, repository *git2go.Repository, head *git2go.Oid) error {
+	tag := enabled(pr, g.Name()+".tag")
+	describe := enabled(pr, g.Name()+".describe")
+	lastCommit := enabled(pr, g.Name()+".last_commit")
+	if !tag && !describe && !lastCommit {
+		return nil
+	}
+	commit, err := repository.LookupCommit(head)
+	if err != nil {
+		return fmt.Errorf("error looking up HEAD commit: %v", err)
+	}
+	defer commit.Free()
+
+	if tag {
+		//with no candidates only a tag at the commit itself describes it
+		g.tag = describeCommit(commit, 0)
+	}
+	if describe {
+		g.describe = describeCommit(commit, 10)
+	}
+	if lastCommit {
+		author := commit.Author()
+		g.commit = commit.Id().String()[:7]
+		g.subject = commit.Summary()
+		g.author = author.Name
+		g.age = now(pr).Sub(author.When)
+	}
+	return nil
+}
+
+// describeCommit returns the nearest tag reachable from commit among the candidates nearest
+// ones, followed by the commits since it and the commit id if it is not at commit, as git
+// describe --tags. It is "" if no tag describes commit
+func describeCommit(commit *git2go.Commit, candidates uint) string {
+	opts, err := git2go.DefaultDescribeOptions()
+	if err != nil {
+		return ""
+	}
+	opts.Strategy = git2go.DescribeTags
+	opts.MaxCandidatesTags = candidates
+	result, err := commit.Describe(&opts)
+	if err != nil {
+		return ""
+	}
+	defer result.Free()
+	formatOpts, err := git2go.DefaultDescribeFormatOptions()
+	if err != nil {
+		return ""
+	}
+	described, err := result.Format(&formatOpts)
+	if err != nil {
+		return ""
+	}
+	return described
+}
+
+// age returns d in its largest whole unit: 45s, 5m, 3h, 2d, 6w or 1y
+func age(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d < time.Minute:
+		if d < 0 {
+			d = 0
+		}
+		return strconv.Itoa(int(d/time.Second)) + "s"
+	case d < time.Hour:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	case d < day:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	case d < 7*day:
+		return strconv.Itoa(int(d/day)) + "d"
+	case d < 365*day:
+		return strconv.Itoa(int(d/(7*day))) + "w"
+	default:
+		return strconv.Itoa(int(d/(365*day))) + "y"
+	}
+}
+
+// worktreeName returns the name of the linked worktree repository is, "" if it is not one
+func worktreeName(repository *git2go.Repository) string {
+	gitdir, err := repository.ItemPath(git2go.RepositoryItemGitDir)
+	if err != nil {
+		return ""
+	}
+	commondir, err := repository.ItemPath(git2go.RepositoryItemCommonDir)
+	if err != nil || filepath.Clean(gitdir) == filepath.Clean(commondir) {
+		return ""
+	}
+	//linked worktrees have their own git dir, .git/worktrees/<name>, in the main one
+	return filepath.Base(filepath.Clean(gitdir))
+}
+
+// superproject returns the name of the repository the submodule checked out in workdir
+// belongs to, and whether its head differs from the commit the superproject records. The
+// name is "" if workdir is not a submodule
+func superproject(workdir string, head *git2go.Oid) (string, bool) {
+	if workdir == "" {
+		return "", false
+	}
+	dir := filepath.Clean(workdir)
+	gitpath, err := git2go.Discover(filepath.Dir(dir), false, []string{"/"})
+	if err != nil {
+		return "", false
+	}
+	super, err := git2go.OpenRepository(gitpath)
+	if err != nil {
+		return "", false
+	}
+	defer super.Free()
+	if super.Workdir() == "" {
+		return "", false
+	}
+	superdir := filepath.Clean(super.Workdir())
+	path, err := filepath.Rel(superdir, dir)
+	if err != nil {
+		return "", false
+	}
+	//a repository nested in another one is a submodule only if the other one says so
+	submodule, err := super.Submodules.Lookup(filepath.ToSlash(path))
+	if err != nil {
+		return "", false
+	}
+	defer submodule.Free()
+	recorded := submodule.HeadId()
+	return filepath.Base(superdir), recorded != nil && !recorded.Equal(head)
+}
+
+// loadStatus counts the files in the workdir and the index of repository by status
+func (g *Git) loadStatus(repository *git2go.Repository) error {
+	//Get current tracked & untracked files status
+	statusOpts := git2go.StatusOptions{
+		Flags: git2go.StatusOptIncludeUntracked | git2go.StatusOptRenamesHeadToIndex,
+	}
+	repostate, err := repository.StatusList(&statusOpts)
+	if err != nil {
+		return fmt.Errorf("error getting repository status at %s: %v", repository.Path(), err)
+	}
+	defer repostate.Free()
+	n, err := repostate.EntryCount()
+	if err != nil {
+		return fmt.Errorf("error getting repository status entry count at %s: %v", repository.Path(), err)
+	}
+	for i := 0; i < n; i++ {
+		entry, _ := repostate.ByIndex(i)
+		got := false
+		if entry.Status&git2go.StatusCurrent > 0 {
+			got = true
+		}
+		if entry.Status&git2go.StatusIndexNew > 0 {
+			g.staged++
+			got = true
+		}
+		if entry.Status&git2go.StatusIndexModified > 0 {
+			g.staged++
+			got = true
+		}
+		if entry.Status&git2go.StatusIndexDeleted > 0 {
+			g.staged++
+			got = true
+		}
+		if entry.Status&git2go.StatusIndexRenamed > 0 {
+			g.staged++
+			got = true
+		}
+		if entry.Status&git2go.StatusIndexTypeChange > 0 {
+			g.staged++
+			got = true
+		}
+		if entry.Status&git2go.StatusWtNew > 0 {
+			g.untracked++
+			got = true
+		}
+		if entry.Status&git2go.StatusWtModified > 0 {
+			//a modified submodule is a commit in the workdir
+			if entry.IndexToWorkdir.NewFile.Mode == uint16(git2go.FilemodeCommit) {
+				g.submodules++
+			} else {
+				g.changed++
 			}
+			got = true
+		}
+		if entry.Status&git2go.StatusWtDeleted > 0 {
+			g.changed++
+			got = true
+		}
+		if entry.Status&git2go.StatusWtTypeChange > 0 {
+			g.changed++
+			got = true
+		}
+		if entry.Status&git2go.StatusWtRenamed > 0 {
+			g.changed++
+			got = true
+		}
+		if entry.Status&git2go.StatusIgnored > 0 {
+			got = true
+		}
+		if entry.Status&git2go.StatusConflicted > 0 {
+			g.conflicted++
+			got = true
+		}
+		if !got {
+			log.Println("Git plugin. Unknown: ", entry.Status)
 		}
 	}
 	return nil
 }
 
 // Get returns the string to use in the prompt
-func (g Git) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (g Git) Get(format theme.Formatter) (string, theme.Role) {
 	var gitPromptInfo string
 	if g.branch != "" {
-		gitPromptInfo = format(g.branch, termcolor.FgMagenta)
+		if g.bare {
+			gitPromptInfo += format(g.icons.Icon("git.bare"), "git.bare") + " "
+		}
+		if g.worktree != "" {
+			gitPromptInfo += format(g.icons.Icon("git.worktree")+g.worktree, "git.worktree") + " "
+		}
+		if g.superproject != "" {
+			submodule := g.icons.Icon("git.submodule") + g.superproject
+			if g.submoduleChanged {
+				submodule += g.icons.Icon("git.unrecorded")
+			}
+			gitPromptInfo += format(submodule, "git.submodule") + " "
+		}
+		gitPromptInfo += format(g.icons.Prefix("git.branch")+g.branch, "git.branch")
+		if g.tag != "" {
+			gitPromptInfo += " " + format(g.icons.Icon("git.tag")+g.tag, "git.tag")
+		}
+		if g.describe != "" && g.describe != g.tag {
+			gitPromptInfo += " " + format(g.icons.Icon("git.tag")+g.describe, "git.describe")
+		}
+		if g.commit != "" {
+			gitPromptInfo += " " + format(g.icons.Icon("git.commit")+g.commit+" "+age(g.age), "git.commit")
+		}
 		space := " "
 		if g.commitsBehind > 0 {
-			gitPromptInfo += space + sDownArrow + "·" + strconv.Itoa(g.commitsBehind)
+			gitPromptInfo += space + g.icons.Icon("git.behind") + strconv.Itoa(g.commitsBehind)
 			space = ""
 		}
 		if g.commitsAhead > 0 {
-			gitPromptInfo += space + sUpArrow + "·" + strconv.Itoa(g.commitsAhead)
+			gitPromptInfo += space + g.icons.Icon("git.ahead") + strconv.Itoa(g.commitsAhead)
 			space = ""
 		}
 		if !g.hasUpstream {
-			gitPromptInfo += space + sAsterisk
+			gitPromptInfo += space + g.icons.Icon("git.noupstream")
 			space = ""
 		}
+		//a bare repository has no files to show the status of
+		if g.bare {
+			return gitPromptInfo, "git"
+		}
 		gitPromptInfo += "|"
 		synced := true
 		if g.conflicted > 0 {
-			gitPromptInfo += format(sCross+strconv.Itoa(g.conflicted), termcolor.FgRed)
+			gitPromptInfo += format(g.icons.Icon("git.conflicted")+strconv.Itoa(g.conflicted), "git.conflicted")
 			synced = false
 		}
 		if g.staged > 0 {
-			gitPromptInfo += format(sDot+strconv.Itoa(g.staged), termcolor.FgCyan)
+			gitPromptInfo += format(g.icons.Icon("git.staged")+strconv.Itoa(g.staged), "git.staged")
 			synced = false
 		}
 		if g.changed > 0 {
-			gitPromptInfo += format("+"+strconv.Itoa(g.changed), termcolor.FgCyan)
+			gitPromptInfo += format(g.icons.Icon("git.changed")+strconv.Itoa(g.changed), "git.changed")
+			synced = false
+		}
+		if g.submodules > 0 {
+			gitPromptInfo += format(g.icons.Icon("git.submodules")+strconv.Itoa(g.submodules), "git.submodules")
 			synced = false
 		}
 		if g.untracked > 0 {
-			gitPromptInfo += format(sThreeDots+strconv.Itoa(g.untracked), termcolor.FgCyan)
+			gitPromptInfo += format(g.icons.Icon("git.untracked")+strconv.Itoa(g.untracked), "git.untracked")
 			synced = false
 		}
 		if synced {
-			gitPromptInfo += format(sCheck, termcolor.FgHiGreen)
+			gitPromptInfo += format(g.icons.Icon("git.clean"), "git.clean")
 		}
 		if g.stashed > 0 {
-			gitPromptInfo += format(sFlag+strconv.Itoa(g.stashed), termcolor.FgHiMagenta)
+			gitPromptInfo += format(g.icons.Icon("git.stashed")+strconv.Itoa(g.stashed), "git.stashed")
 		}
 	}
-	return gitPromptInfo, []termcolor.Mode{termcolor.FgMagenta}
+	return gitPromptInfo, "git"
 }
 
-func lineCounter(r io.Reader) (int, error) {
-	buf := make([]byte, 32*1024)
-	count := 0
-	lineSep := []byte{'\n'}
-
-	for {
-		c, err := r.Read(buf)
-		count += bytes.Count(buf[:c], lineSep)
-
-		switch {
-		case err == io.EOF:
-			return count, nil
+// Data returns the plugin state as structured data
+func (g Git) Data() map[string]interface{} {
+	if g.branch == "" {
+		return nil
+	}
+	data := map[string]interface{}{
+		"branch":            g.branch,
+		"detached":          g.detached,
+		"upstream":          g.hasUpstream,
+		"ahead":             g.commitsAhead,
+		"behind":            g.commitsBehind,
+		"conflicted":        g.conflicted,
+		"staged":            g.staged,
+		"changed":           g.changed,
+		"untracked":         g.untracked,
+		"submodules":        g.submodules,
+		"stashed":           g.stashed,
+		"bare":              g.bare,
+		"worktree":          g.worktree,
+		"superproject":      g.superproject,
+		"submodule_changed": g.submoduleChanged,
+		"tag":               g.tag,
+		"describe":          g.describe,
+	}
+	if g.commit != "" {
+		data["commit"] = g.commit
+		data["subject"] = g.subject
+		data["author"] = g.author
+		data["age"] = age(g.age)
+	}
+	return data
+}
 
-		case err != nil:
-			return count, err
+// stashCount returns the number of stashes in repository. They are read from the stash
+// reflog, shared by all the worktrees; if it is missing or expired only the last stash is known
+func stashCount(repository *git2go.Repository) (int, error) {
+	count := 0
+	err := repository.Stashes.Foreach(func(int, string, *git2go.Oid) error {
+		count++
+		return nil
+	})
+	if err != nil && !git2go.IsErrorCode(err, git2go.ErrorCodeNotFound) {
+		return 0, err
+	}
+	if count == 0 {
+		if ref, err := repository.References.Lookup("refs/stash"); err == nil {
+			ref.Free()
+			count = 1
 		}
 	}
+	return count, nil
 }
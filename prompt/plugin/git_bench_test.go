@@ -0,0 +1,39 @@
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/internal/fixture"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
+)
+
+func BenchmarkGit(b *testing.B) {
+	repos := map[string]fixture.Repo{"small": fixture.Small, "large": fixture.Large}
+	for _, name := range []string{"small", "large"} {
+		dir := fixture.Generate(b, repos[name])
+		b.Run(name, func(b *testing.B) {
+			fixture.Chdir(b, dir)
+			pr := &plugintest.Prompter{Options: map[string]interface{}{"git.fetch_interval": time.Duration(0)}, Dir: dir}
+			for i := 0; i < b.N; i++ {
+				g := &Git{}
+				if err := g.Load(pr); err != nil {
+					b.Fatal(err)
+				}
+				g.Get(bashFormat)
+			}
+		})
+	}
+}
+
+func BenchmarkPath(b *testing.B) {
+	dir := fixture.Generate(b, fixture.Small)
+	pr := &plugintest.Prompter{Options: map[string]interface{}{"path.fullpath": 2}, Env: map[string]string{"PWD": dir}}
+	for i := 0; i < b.N; i++ {
+		p := &Path{}
+		if err := p.Load(pr); err != nil {
+			b.Fatal(err)
+		}
+		p.Get(bashFormat)
+	}
+}
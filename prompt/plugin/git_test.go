@@ -0,0 +1,220 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/internal/fixture"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestGitStashes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-git")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	repo := filepath.Join(dir, "repo")
+	fixture.Init(t, repo, fixture.Stashed)
+	worktree := filepath.Join(dir, "worktree")
+	fixture.Git(t, repo, "worktree", "add", "-q", worktree)
+	noReflog := filepath.Join(dir, "noreflog")
+	fixture.Init(t, noReflog, fixture.Dirty)
+	fixture.Git(t, noReflog, "config", "core.logAllRefUpdates", "false")
+	fixture.Git(t, noReflog, "stash", "-q")
+	expired := filepath.Join(dir, "expired")
+	fixture.Init(t, expired, fixture.Stashed)
+	fixture.Git(t, expired, "reflog", "expire", "--expire=now", "--all")
+	clean := filepath.Join(dir, "clean")
+	fixture.Init(t, clean, fixture.Clean)
+
+	testCases := []struct {
+		name     string
+		dir      string
+		expected int
+	}{
+		{name: "repository", dir: repo, expected: 2},
+		{name: "linked worktree", dir: worktree, expected: 2},
+		{name: "reflog disabled", dir: noReflog, expected: 1},
+		{name: "reflog expired", dir: expired, expected: 1},
+		{name: "no stashes", dir: clean, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &Git{}
+			pr := &plugintest.Prompter{Options: map[string]interface{}{"git.fetch_interval": time.Duration(0)}, Dir: tc.dir}
+			if err := g.Load(pr); err != nil {
+				t.Fatalf("unable to load: %v", err)
+			}
+			if g.stashed != tc.expected {
+				t.Errorf("expected %d stashes, got %d", tc.expected, g.stashed)
+			}
+		})
+	}
+}
+
+func TestGitContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-git")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	worktree := fixture.Init(t, filepath.Join(dir, "feature"), fixture.Worktree)
+	bare := fixture.Init(t, filepath.Join(dir, "bare.git"), fixture.Bare)
+	submodule := fixture.Init(t, filepath.Join(dir, "app"), fixture.Submodule)
+	superproject := filepath.Join(dir, "app")
+	//a repository inside another one that is not a submodule of it
+	nested := filepath.Join(dir, "outer", "inner")
+	fixture.Init(t, filepath.Join(dir, "outer"), fixture.Clean)
+	fixture.Init(t, nested, fixture.Clean)
+
+	testCases := []struct {
+		name     string
+		dir      string
+		expected map[string]interface{}
+	}{
+		{name: "linked worktree", dir: worktree, expected: map[string]interface{}{"worktree": "feature", "superproject": "", "bare": false}},
+		{name: "bare", dir: bare, expected: map[string]interface{}{"worktree": "", "bare": true, "branch": "main"}},
+		{name: "submodule", dir: submodule, expected: map[string]interface{}{"superproject": "app", "submodule_changed": true, "changed": 0}},
+		{name: "superproject", dir: superproject, expected: map[string]interface{}{"superproject": "", "submodules": 1, "changed": 0}},
+		{name: "nested", dir: nested, expected: map[string]interface{}{"superproject": "", "worktree": ""}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &Git{}
+			pr := &plugintest.Prompter{Options: map[string]interface{}{"git.fetch_interval": time.Duration(0)}, Dir: tc.dir}
+			if err := g.Load(pr); err != nil {
+				t.Fatalf("unable to load: %v", err)
+			}
+			data := g.Data()
+			for k, v := range tc.expected {
+				if data[k] != v {
+					t.Errorf("expected %s %v, got %v", k, v, data[k])
+				}
+			}
+		})
+	}
+}
+
+func TestGitHead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-git")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tagged := filepath.Join(dir, "tagged")
+	fixture.Init(t, tagged, fixture.Tagged)
+	released := filepath.Join(dir, "released")
+	fixture.Init(t, released, fixture.Tagged)
+	fixture.Git(t, released, "tag", "v1.1")
+
+	all := map[string]interface{}{"git.tag": true, "git.describe": true, "git.last_commit": true}
+	testCases := []struct {
+		name     string
+		dir      string
+		options  map[string]interface{}
+		expected string
+	}{
+		{name: "disabled", dir: tagged, options: map[string]interface{}{}, expected: "main ⭑|✔"},
+		{name: "after a tag", dir: tagged, options: all, expected: "main #v1.0-1-ga5bcf02 @a5bcf02 12h ⭑|✔"},
+		{name: "at a tag", dir: released, options: all, expected: "main #v1.1 @a5bcf02 12h ⭑|✔"},
+		{name: "tag only", dir: tagged, options: map[string]interface{}{"git.tag": true}, expected: "main ⭑|✔"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &Git{}
+			tc.options["git.fetch_interval"] = time.Duration(0)
+			pr := &plugintest.Prompter{Options: tc.options, Dir: tc.dir, Clock: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+			if err := g.Load(pr); err != nil {
+				t.Fatalf("unable to load: %v", err)
+			}
+			if output, _ := g.Get(theme.Default().Formatter(theme.Renderer{Target: theme.Plain})); output != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, output)
+			}
+		})
+	}
+	g := &Git{}
+	if err := g.Load(&plugintest.Prompter{Options: all, Dir: released}); err != nil {
+		t.Fatalf("unable to load: %v", err)
+	}
+	if data := g.Data(); data["subject"] != "commit 2" || data["author"] != "goprompt" {
+		t.Errorf("unexpected last commit data %v", data)
+	}
+}
+
+func TestAge(t *testing.T) {
+	testCases := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{-time.Second, "0s"},
+		{45 * time.Second, "45s"},
+		{5*time.Minute + 30*time.Second, "5m"},
+		{3 * time.Hour, "3h"},
+		{50 * time.Hour, "2d"},
+		{20 * 24 * time.Hour, "2w"},
+		{800 * 24 * time.Hour, "2y"},
+	}
+	for _, tc := range testCases {
+		if got := age(tc.d); got != tc.expected {
+			t.Errorf("age(%v): expected %s, got %s", tc.d, tc.expected, got)
+		}
+	}
+}
+
+func TestGitFetchTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-git")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	repo := filepath.Join(dir, "repo")
+	fixture.Init(t, repo, fixture.Diverged)
+
+	pr := &plugintest.Prompter{Options: map[string]interface{}{"git.fetch_interval": time.Hour}, Dir: repo, Clock: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	key := "git-" + repo + "-fetch"
+	//a value of another type must not break the load, the fetch time is stored as a string
+	pr.Cache(key, time.Now())
+	for i := 0; i < 2; i++ {
+		if err := (&Git{}).Load(pr); err != nil {
+			t.Fatalf("unable to load: %v", err)
+		}
+		if last, _ := pr.GetCache(key); last != "2020-01-01T12:00:00Z" {
+			t.Errorf("expected the fetch time to be cached as a string, got %v", last)
+		}
+	}
+}
+
+func TestGitReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-git")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	repo := filepath.Join(dir, "repo")
+	fixture.Init(t, repo, fixture.Dirty)
+
+	g := &Git{}
+	options := map[string]interface{}{"git.fetch_interval": time.Duration(0)}
+	for i := 0; i < 2; i++ {
+		if err := g.Load(&plugintest.Prompter{Options: options, Dir: repo}); err != nil {
+			t.Fatalf("unable to load: %v", err)
+		}
+		if data := g.Data(); data["staged"] != 1 || data["changed"] != 1 || data["untracked"] != 1 {
+			t.Errorf("load %d: unexpected status %v", i, data)
+		}
+	}
+	if err := g.Load(&plugintest.Prompter{Options: options, Dir: dir}); err != nil {
+		t.Fatalf("unable to load: %v", err)
+	}
+	if data := g.Data(); data != nil {
+		t.Errorf("expected nothing outside a repository, got %v", data)
+	}
+}
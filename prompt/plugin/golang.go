@@ -3,12 +3,14 @@ package plugin
 import (
 	"runtime"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 //Golang is the plugin struct
 type Golang struct {
 	version string
+	icons   icon.Set
 }
 
 //Name returns the plugin name
@@ -17,18 +19,27 @@ func (Golang) Name() string {
 }
 
 //Help returns help information about this plugin
-func (Golang) Help() (description string, options map[string]string) {
+func (Golang) Help() (description string, options []Option) {
 	description = "This plugins show current golang version"
 	return
 }
 
 //Load is the load function of the plugin
-func (g *Golang) Load(Prompter) error {
+func (g *Golang) Load(pr Prompter) error {
+	*g = Golang{}
+	g.icons = icons(pr)
 	g.version = runtime.Version()
 	return nil
 }
 
 //Get returns the string to use in the prompt
-func (g Golang) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
-	return format(g.version, termcolor.FgBlue), []termcolor.Mode{termcolor.FgBlue}
+func (g Golang) Get(format theme.Formatter) (string, theme.Role) {
+	return format(g.icons.Prefix("golang")+g.version, "golang.version"), "golang"
+}
+
+//Data returns the plugin state as structured data
+func (g Golang) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"version": g.version,
+	}
 }
@@ -3,8 +3,6 @@ package plugin
 import (
 	"runtime"
 	"testing"
-
-	"github.com/josledp/termcolor"
 )
 
 func TestGolang(t *testing.T) {
@@ -18,7 +16,7 @@ func TestGolang(t *testing.T) {
 		t.Error("Invalid golang version")
 	}
 
-	output, _ := g.Get(termcolor.EscapedFormat)
+	output, _ := g.Get(bashFormat)
 	if output != expected {
 		t.Errorf("Expected %s\nGot      %s", expected, output)
 	}
@@ -2,10 +2,9 @@ package plugin
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // Hostname is the plugin struct
@@ -20,17 +19,18 @@ func (Hostname) Name() string {
 }
 
 // Help returns help information about this plugin
-func (Hostname) Help() (description string, options map[string]string) {
+func (Hostname) Help() (description string, options []Option) {
 	description = "This plugins shows the current hostname (red if you are root, green otherwise)"
 	return
 }
 
 // Load is the load function of the plugin
-func (h *Hostname) Load(Prompter) error {
+func (h *Hostname) Load(pr Prompter) error {
+	*h = Hostname{}
 	var err error
-	h.user = os.Getenv("USER")
+	h.user = getenv(pr, "USER")
 
-	h.hostname, err = os.Hostname()
+	h.hostname, err = hostname(pr)
 	if err != nil {
 		return fmt.Errorf("unable to get Hostname: %v", err)
 	}
@@ -40,9 +40,17 @@ func (h *Hostname) Load(Prompter) error {
 }
 
 // Get returns the string to use in the prompt
-func (h Hostname) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (h Hostname) Get(format theme.Formatter) (string, theme.Role) {
 	if h.user == "root" {
-		return format(h.hostname, termcolor.Bold, termcolor.FgRed), []termcolor.Mode{termcolor.FgRed}
+		return format(h.hostname, "hostname.root"), "hostname.root"
+	}
+	return format(h.hostname, "hostname.name"), "hostname"
+}
+
+// Data returns the plugin state as structured data
+func (h Hostname) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"hostname": h.hostname,
+		"root":     h.user == "root",
 	}
-	return format(h.hostname, termcolor.Bold, termcolor.FgGreen), []termcolor.Mode{termcolor.FgGreen}
 }
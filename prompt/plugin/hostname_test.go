@@ -1,17 +1,13 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestHostname(t *testing.T) {
-	host, err := os.Hostname()
-	if err != nil {
-		t.Fatal("Unable to get hostname")
-	}
+	host := "myhost"
 	testCases := []struct {
 		user     string
 		expected string
@@ -27,10 +23,12 @@ func TestHostname(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
+		tc := tc
 		t.Run(tc.user, func(t *testing.T) {
-			os.Setenv("USER", tc.user)
+			t.Parallel()
+			pr := &plugintest.Prompter{Env: map[string]string{"USER": tc.user}, Host: host + ".example.com"}
 			h := &Hostname{}
-			h.Load(nil)
+			h.Load(pr)
 
 			if h.user != tc.user {
 				t.Error("Invalid user")
@@ -40,7 +38,7 @@ func TestHostname(t *testing.T) {
 				t.Error("Invalid host")
 			}
 
-			output, _ := h.Get(termcolor.EscapedFormat)
+			output, _ := h.Get(bashFormat)
 			if output != tc.expected {
 				t.Errorf("Expected %s\nGot      %s", tc.expected, output)
 			}
@@ -2,10 +2,10 @@ package plugin
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -13,6 +13,7 @@ import (
 type Kubernetes struct {
 	context   string
 	namespace string
+	icons     icon.Set
 }
 
 type k8sconfig struct {
@@ -41,27 +42,24 @@ func (Kubernetes) Name() string {
 }
 
 //Help returns help information about this plugin
-func (Kubernetes) Help() (description string, options map[string]string) {
+func (Kubernetes) Help() (description string, options []Option) {
 	description = "This plugins show the current context for kubernetes with its namespace"
 	return
 }
 
 //Load is the load function of the plugin
-func (k *Kubernetes) Load(Prompter) error {
-	file := os.Getenv("KUBECONFIG")
+func (k *Kubernetes) Load(pr Prompter) error {
+	*k = Kubernetes{}
+	k.icons = icons(pr)
+	file := getenv(pr, "KUBECONFIG")
 	if file == "" {
-		file = os.Getenv("HOME") + string(os.PathSeparator) + ".kube/config"
+		file = getenv(pr, "HOME") + string(os.PathSeparator) + ".kube/config"
 	}
-	if _, err := os.Stat(file); err != nil {
+	if _, err := stat(pr, file); err != nil {
 		return nil
 	}
 
-	f, err := os.Open(file)
-	if err != nil {
-		return fmt.Errorf("unable to open %s: %v", file, err)
-	}
-	defer f.Close()
-	data, err := ioutil.ReadAll(f)
+	data, err := readFile(pr, file)
 	if err != nil {
 		return fmt.Errorf("unable to read file %s: %v", file, err)
 	}
@@ -87,9 +85,20 @@ func (k *Kubernetes) Load(Prompter) error {
 }
 
 //Get returns the string to use in the prompt
-func (k Kubernetes) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (k Kubernetes) Get(format theme.Formatter) (string, theme.Role) {
 	if k.context != "" {
-		return format(fmt.Sprintf("%s(%s)", k.context, k.namespace), termcolor.FgHiBlue), []termcolor.Mode{termcolor.FgHiBlue}
+		return format(k.icons.Prefix("k8s")+fmt.Sprintf("%s(%s)", k.context, k.namespace), "k8s.context"), "k8s"
+	}
+	return "", ""
+}
+
+//Data returns the plugin state as structured data
+func (k Kubernetes) Data() map[string]interface{} {
+	if k.context == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"context":   k.context,
+		"namespace": k.namespace,
 	}
-	return "", nil
 }
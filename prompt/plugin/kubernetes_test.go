@@ -1,14 +1,26 @@
 package plugin
 
 import (
-	"os"
+	"io/ioutil"
 	"testing"
+	"testing/fstest"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestKubernetes(t *testing.T) {
+	files := fstest.MapFS{}
+	for _, name := range []string{"config1", "config2"} {
+		data, err := ioutil.ReadFile("../../testdata/" + name)
+		if err != nil {
+			t.Fatalf("unable to read %s: %v", name, err)
+		}
+		files["src/goprompt/testdata/"+name] = &fstest.MapFile{Data: data}
+	}
+	files["home/test/.kube/config"] = files["src/goprompt/testdata/config2"]
+
 	testCases := []struct {
+		name              string
 		kubeConfig        string
 		expectedContext   string
 		expectedNamespace string
@@ -26,13 +38,27 @@ func TestKubernetes(t *testing.T) {
 			expectedNamespace: "namespacex",
 			expectedPrompt:    "\\[\\033[0m\\]\\[\\033[94m\\]cluster1_context(namespacex)\\[\\033[0m\\]",
 		},
+		{
+			name:              "home",
+			expectedContext:   "cluster1_context",
+			expectedNamespace: "namespacex",
+			expectedPrompt:    "\\[\\033[0m\\]\\[\\033[94m\\]cluster1_context(namespacex)\\[\\033[0m\\]",
+		},
 	}
 
 	for _, tc := range testCases {
-		t.Run(tc.expectedContext, func(t *testing.T) {
-			os.Setenv("KUBECONFIG", tc.kubeConfig)
+		tc := tc
+		t.Run(tc.expectedContext+tc.name, func(t *testing.T) {
+			t.Parallel()
+			pr := &plugintest.Prompter{
+				Env:   map[string]string{"KUBECONFIG": tc.kubeConfig, "HOME": "/home/test"},
+				Dir:   "/src/goprompt/prompt/plugin",
+				Files: files,
+			}
 			k := &Kubernetes{}
-			k.Load(nil)
+			if err := k.Load(pr); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
 
 			if k.context != tc.expectedContext {
 				t.Errorf("Expected context: %s, got %s", tc.expectedContext, k.context)
@@ -41,7 +67,7 @@ func TestKubernetes(t *testing.T) {
 				t.Errorf("Expected namespace: %s, got %s", tc.expectedNamespace, k.namespace)
 			}
 
-			output, _ := k.Get(termcolor.EscapedFormat)
+			output, _ := k.Get(bashFormat)
 			if output != tc.expectedPrompt {
 				t.Errorf("Expected %s\nGot      %s", tc.expectedPrompt, output)
 			}
@@ -2,9 +2,8 @@ package plugin
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // LastCommand is the plugin struct
@@ -18,15 +17,15 @@ func (LastCommand) Name() string {
 }
 
 // Help returns help information about this plugin
-func (LastCommand) Help() (description string, options map[string]string) {
+func (LastCommand) Help() (description string, options []Option) {
 	description = "This plugins show the last command return code"
 	return
 }
 
 // Load is the load function of the plugin
-func (lc *LastCommand) Load(Prompter) error {
-
-	lc.lastrc = os.Getenv("LAST_COMMAND_RC")
+func (lc *LastCommand) Load(pr Prompter) error {
+	*lc = LastCommand{}
+	lc.lastrc = getenv(pr, "LAST_COMMAND_RC")
 	if lc.lastrc == "" {
 		return fmt.Errorf("unable to get LAST_COMMAND_RC")
 	}
@@ -36,9 +35,19 @@ func (lc *LastCommand) Load(Prompter) error {
 }
 
 // Get returns the string to use in the prompt
-func (lc LastCommand) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (lc LastCommand) Get(format theme.Formatter) (string, theme.Role) {
 	if lc.lastrc != "" {
-		return format(lc.lastrc, termcolor.FgHiYellow), []termcolor.Mode{termcolor.FgHiYellow}
+		return format(lc.lastrc, "lastcommand.rc"), "lastcommand"
+	}
+	return "", ""
+}
+
+// Data returns the plugin state as structured data
+func (lc LastCommand) Data() map[string]interface{} {
+	if lc.lastrc == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"rc": lc.lastrc,
 	}
-	return "", nil
 }
@@ -1,25 +1,24 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestLastCommand(t *testing.T) {
-
-	os.Setenv("LAST_COMMAND_RC", "10")
+	t.Parallel()
+	pr := &plugintest.Prompter{Env: map[string]string{"LAST_COMMAND_RC": "10"}}
 	expected := "\\[\\033[0m\\]\\[\\033[93m\\]10\\[\\033[0m\\]"
 
 	lc := &LastCommand{}
-	lc.Load(nil)
+	lc.Load(pr)
 
 	if lc.lastrc != "10" {
 		t.Error("Invalid Last command rc")
 	}
 
-	output, _ := lc.Get(termcolor.EscapedFormat)
+	output, _ := lc.Get(bashFormat)
 	if output != expected {
 		t.Errorf("Expected %s\nGot      %s", expected, output)
 	}
@@ -28,12 +27,13 @@ func TestLastCommand(t *testing.T) {
 
 func TestLastCommandError(t *testing.T) {
 
-	os.Setenv("LAST_COMMAND_RC", "")
+	t.Parallel()
+	pr := &plugintest.Prompter{Env: map[string]string{"LAST_COMMAND_RC": ""}}
 
 	lc := &LastCommand{}
-	err := lc.Load(nil)
+	err := lc.Load(pr)
 
-	if err.Error() != "Unable to get LAST_COMMAND_RC" {
+	if err == nil || err.Error() != "unable to get LAST_COMMAND_RC" {
 		t.Errorf("Invalid Last command Error: %v", err)
 	}
 
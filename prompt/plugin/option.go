@@ -0,0 +1,179 @@
+package plugin
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+// OptionType is the type of the values an option accepts
+type OptionType string
+
+const (
+	// OptionInt is an integer option (int). Booleans are accepted as 1 (true) and 0 (false)
+	OptionInt OptionType = "int"
+	// OptionBool is a boolean option (bool)
+	OptionBool OptionType = "bool"
+	// OptionString is a string option (string)
+	OptionString OptionType = "string"
+	// OptionDuration is a duration option (time.Duration), as a string ("5m") or a number of seconds
+	OptionDuration OptionType = "duration"
+	// OptionEnum is a string option (string) restricted to the Allowed values
+	OptionEnum OptionType = "enum"
+	// OptionColor is a style spec option like "bold red" (theme.Style)
+	OptionColor OptionType = "color"
+	// OptionRegex is a regular expression option (*regexp.Regexp)
+	OptionRegex OptionType = "regex"
+)
+
+// Option describes an option accepted by a plugin. Default holds the value already
+// converted to the Go type of the option (see OptionType)
+type Option struct {
+	Name        string
+	Type        OptionType
+	Default     interface{}
+	Allowed     []interface{}
+	Description string
+}
+
+// Convert validates a value decoded from a config file (float64, bool or string), or
+// an already converted one, and returns it converted to the Go type of the option
+func (o Option) Convert(value interface{}) (interface{}, error) {
+	var v interface{}
+	switch o.Type {
+	case OptionInt:
+		switch t := value.(type) {
+		case float64:
+			if t != math.Trunc(t) {
+				return nil, fmt.Errorf("expecting an integer, got %v", t)
+			}
+			v = int(t)
+		case int:
+			v = t
+		case bool:
+			v = 0
+			if t {
+				v = 1
+			}
+		default:
+			return nil, fmt.Errorf("expecting an integer, got %v (%s)", value, typeName(value))
+		}
+	case OptionBool:
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("expecting a boolean, got %v (%s)", value, typeName(value))
+		}
+		v = value
+	case OptionString, OptionEnum:
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("expecting a string, got %v (%s)", value, typeName(value))
+		}
+		v = value
+	case OptionDuration:
+		switch t := value.(type) {
+		case float64:
+			v = time.Duration(t * float64(time.Second))
+		case time.Duration:
+			v = t
+		case string:
+			d, err := time.ParseDuration(t)
+			if err != nil {
+				return nil, fmt.Errorf("expecting a duration: %v", err)
+			}
+			v = d
+		default:
+			return nil, fmt.Errorf("expecting a duration, got %v (%s)", value, typeName(value))
+		}
+	case OptionColor:
+		if st, ok := value.(theme.Style); ok {
+			v = st
+			break
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a style, got %v (%s)", value, typeName(value))
+		}
+		st, err := theme.ParseStyle(s)
+		if err != nil {
+			return nil, err
+		}
+		v = st
+	case OptionRegex:
+		if re, ok := value.(*regexp.Regexp); ok {
+			v = re
+			break
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expecting a regular expression, got %v (%s)", value, typeName(value))
+		}
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %v", err)
+		}
+		v = re
+	default:
+		return nil, fmt.Errorf("unknown option type %s", o.Type)
+	}
+
+	if len(o.Allowed) == 0 {
+		return v, nil
+	}
+	allowed := make([]string, 0, len(o.Allowed))
+	for _, a := range o.Allowed {
+		if a == v {
+			return v, nil
+		}
+		allowed = append(allowed, fmt.Sprint(a))
+	}
+	return nil, fmt.Errorf("invalid value %v, allowed values: %s", value, strings.Join(allowed, ", "))
+}
+
+// Parse converts a string value (e.g. from the command line) to the Go type of the option
+func (o Option) Parse(value string) (interface{}, error) {
+	switch o.Type {
+	case OptionInt:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			b, berr := strconv.ParseBool(value)
+			if berr != nil {
+				return nil, fmt.Errorf("expecting an integer, got %s", value)
+			}
+			return o.Convert(b)
+		}
+		return o.Convert(i)
+	case OptionBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("expecting a boolean, got %s", value)
+		}
+		return o.Convert(b)
+	case OptionDuration:
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return o.Convert(f)
+		}
+	}
+	return o.Convert(value)
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,105 @@
+package plugin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestOptionConvert(t *testing.T) {
+	hiblue, _ := theme.ParseColor("hiblue")
+	testCases := []struct {
+		name     string
+		option   Option
+		value    interface{}
+		expected interface{}
+		err      string
+	}{
+		{
+			name:     "int",
+			option:   Option{Type: OptionInt},
+			value:    float64(2),
+			expected: 2,
+		},
+		{
+			name:   "int not integer",
+			option: Option{Type: OptionInt},
+			value:  float64(2.5),
+			err:    "expecting an integer, got 2.5",
+		},
+		{
+			name:     "int from bool",
+			option:   Option{Type: OptionInt},
+			value:    false,
+			expected: 0,
+		},
+		{
+			name:   "int not allowed",
+			option: Option{Type: OptionInt, Allowed: []interface{}{0, 1}},
+			value:  float64(3),
+			err:    "invalid value 3, allowed values: 0, 1",
+		},
+		{
+			name:   "bool from string",
+			option: Option{Type: OptionBool},
+			value:  "true",
+			err:    "expecting a boolean, got true (string)",
+		},
+		{
+			name:     "duration from string",
+			option:   Option{Type: OptionDuration},
+			value:    "1m30s",
+			expected: 90 * time.Second,
+		},
+		{
+			name:     "duration from seconds",
+			option:   Option{Type: OptionDuration},
+			value:    float64(10),
+			expected: 10 * time.Second,
+		},
+		{
+			name:     "enum",
+			option:   Option{Type: OptionEnum, Allowed: []interface{}{"short", "long"}},
+			value:    "long",
+			expected: "long",
+		},
+		{
+			name:     "color",
+			option:   Option{Type: OptionColor},
+			value:    "HiBlue",
+			expected: theme.Style{Fg: hiblue},
+		},
+		{
+			name:   "unknown color",
+			option: Option{Type: OptionColor},
+			value:  "pink",
+			err:    `invalid style "pink": unknown color pink`,
+		},
+		{
+			name:   "invalid regex",
+			option: Option{Type: OptionRegex},
+			value:  "(",
+			err:    "invalid regular expression: error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := tc.option.Convert(tc.value)
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(v, tc.expected) {
+				t.Errorf("expected %v (%T), got %v (%T)", tc.expected, tc.expected, v, v)
+			}
+		})
+	}
+}
@@ -2,10 +2,9 @@ package plugin
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 const maxPathLength = 20
@@ -21,29 +20,36 @@ func (Path) Name() string {
 }
 
 // Help returns help information about this plugin
-func (Path) Help() (description string, options map[string]string) {
+func (Path) Help() (description string, options []Option) {
 	description = "This plugins show the current path"
-	options = map[string]string{
-		"path.fullpath": "if 0 shows just the current dir, 1 for standard full path, 2 for fish path, 3 for variable path (it tries not to shrink it until it is >20)",
+	options = []Option{
+		{
+			Name:        "path.fullpath",
+			Type:        OptionInt,
+			Default:     1,
+			Allowed:     []interface{}{0, 1, 2, 3},
+			Description: "if 0 shows just the current dir, 1 for standard full path, 2 for fish path, 3 for variable path (it tries not to shrink it until it is >20)",
+		},
 	}
 	return
 }
 
 // Load is the load function of the plugin
 func (p *Path) Load(pr Prompter) error {
-	p.pwd = os.Getenv("PWD")
+	*p = Path{}
+	p.pwd = getenv(pr, "PWD")
 	if p.pwd == "" {
 		return fmt.Errorf("unable to get PWD")
 	}
 
-	home := os.Getenv("HOME")
+	home := getenv(pr, "HOME")
 	if home != "" {
 		p.pwd = strings.Replace(p.pwd, home, "~", -1)
 	}
 
 	if pr != nil {
 		if value, ok := pr.GetOption(p.Name() + ".fullpath"); ok {
-			if v, ok := value.(float64); ok {
+			if v, ok := value.(int); ok {
 				switch v {
 				case 0:
 					tmp := strings.Split(p.pwd, "/")
@@ -83,11 +89,6 @@ func (p *Path) Load(pr Prompter) error {
 						}
 					}
 				}
-			} else if v, ok := value.(bool); ok {
-				if !v {
-					tmp := strings.Split(p.pwd, "/")
-					p.pwd = tmp[len(tmp)-1]
-				}
 			} else {
 				return fmt.Errorf("unable to parse path.fullpath option")
 			}
@@ -99,6 +100,13 @@ func (p *Path) Load(pr Prompter) error {
 }
 
 // Get returns the string to use in the prompt
-func (p Path) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
-	return format(p.pwd, termcolor.Bold, termcolor.FgBlue), []termcolor.Mode{termcolor.FgBlue}
+func (p Path) Get(format theme.Formatter) (string, theme.Role) {
+	return format(p.pwd, "path.dir"), "path"
+}
+
+// Data returns the plugin state as structured data
+func (p Path) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"path": p.pwd,
+	}
 }
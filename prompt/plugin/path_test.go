@@ -1,10 +1,9 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestPath(t *testing.T) {
@@ -44,7 +43,7 @@ func TestPath(t *testing.T) {
 					"PWD": "/tmp/test",
 				},
 			},
-			options:        map[string]interface{}{"path.fullpath": float64(0)},
+			options:        map[string]interface{}{"path.fullpath": 0},
 			expectedPwd:    "test",
 			expectedPrompt: "\\[\\033[0m\\]\\[\\033[1;34m\\]test\\[\\033[0m\\]",
 		},
@@ -55,7 +54,7 @@ func TestPath(t *testing.T) {
 					"PWD": "/tmp/test",
 				},
 			},
-			options:        map[string]interface{}{"path.fullpath": float64(2)},
+			options:        map[string]interface{}{"path.fullpath": 2},
 			expectedPwd:    "/t/test",
 			expectedPrompt: "\\[\\033[0m\\]\\[\\033[1;34m\\]/t/test\\[\\033[0m\\]",
 		},
@@ -66,7 +65,7 @@ func TestPath(t *testing.T) {
 					"PWD": "/tmp/test",
 				},
 			},
-			options:        map[string]interface{}{"path.fullpath": float64(3)},
+			options:        map[string]interface{}{"path.fullpath": 3},
 			expectedPwd:    "/tmp/test",
 			expectedPrompt: "\\[\\033[0m\\]\\[\\033[1;34m\\]/tmp/test\\[\\033[0m\\]",
 		},
@@ -77,25 +76,28 @@ func TestPath(t *testing.T) {
 					"PWD": "/tmp/some_very_long_dir_or_path/test1/test",
 				},
 			},
-			options:        map[string]interface{}{"path.fullpath": float64(3)},
+			options:        map[string]interface{}{"path.fullpath": 3},
 			expectedPwd:    "/t/s/test1/test",
 			expectedPrompt: "\\[\\033[0m\\]\\[\\033[1;34m\\]/t/s/test1/test\\[\\033[0m\\]",
 		},
 	}
 
 	for _, tc := range testCases {
+		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			prompter := &plugintest.Prompter{Options: tc.options, Env: make(map[string]string)}
 			for _, env := range tc.env {
 				for k, v := range env {
 					t.Log(k, v)
-					os.Setenv(k, v)
+					prompter.Env[k] = v
 				}
 				p := &Path{}
-				p.Load(mockPrompt{tc.options})
+				p.Load(prompter)
 				if p.pwd != tc.expectedPwd {
 					t.Fatalf("Pwd do not match:\nGot:      %s\nExpected: %s", p.pwd, tc.expectedPwd)
 				}
-				pr, _ := p.Get(termcolor.EscapedFormat)
+				pr, _ := p.Get(bashFormat)
 				if pr != tc.expectedPrompt {
 					t.Fatalf("Generated prompt do not match:\n%s\n%s", pr, tc.expectedPrompt)
 				}
@@ -107,13 +109,13 @@ func TestPath(t *testing.T) {
 
 func TestPathError(t *testing.T) {
 
-	os.Setenv("PWD", "")
+	pr := &plugintest.Prompter{Env: map[string]string{"PWD": ""}}
 
 	p := &Path{}
-	err := p.Load(nil)
+	err := p.Load(pr)
 
-	if err.Error() != "Unable to get PWD" {
-		t.Errorf("Invalid Last command Error: %v", err)
+	if err == nil || err.Error() != "unable to get PWD" {
+		t.Errorf("Invalid Path Error: %v", err)
 	}
 
 }
@@ -1,25 +1,149 @@
 package plugin
 
-//Prompter is the interface which provides options/config to the plugin
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+//Prompter is the interface which provides options/config to the plugin, and the environment,
+//working directory, hostname, clock and filesystem it sees. The plugintest package provides
+//one for the tests
 type Prompter interface {
 	GetOption(string) (interface{}, bool)
 	GetCache(string) (interface{}, bool)
 	Cache(string, interface{}) error
+	Icons() icon.Set
+	Getenv(string) string
+	Getwd() (string, error)
+	Hostname() (string, error)
+	Now() time.Time
+	//FS is the filesystem, with the paths relative to /
+	FS() fs.FS
 }
 
-type mockPrompt struct {
-	options map[string]interface{}
+// CacheReporter is implemented by the Prompters that want to know when a plugin reuses a
+// cached value instead of computing it, as goprompt explain reports
+type CacheReporter interface {
+	CacheHit()
 }
 
-func (m mockPrompt) GetOption(key string) (interface{}, bool) {
-	value, ok := m.options[key]
-	return value, ok
+// icons returns the icon set of pr, the default one when there is no prompter
+func icons(pr Prompter) icon.Set {
+	if pr == nil {
+		return icon.Set{}
+	}
+	return pr.Icons()
 }
 
-func (m mockPrompt) GetCache(key string) (interface{}, bool) {
-	return nil, false
+// getenv returns the environment variable key seen by pr, the process one when there is no prompter
+func getenv(pr Prompter, key string) string {
+	if pr == nil {
+		return os.Getenv(key)
+	}
+	return pr.Getenv(key)
 }
 
-func (m mockPrompt) Cache(key string, value interface{}) error {
-	return nil
+// getwd returns the working directory seen by pr, the process one when there is no prompter
+func getwd(pr Prompter) (string, error) {
+	if pr == nil {
+		return os.Getwd()
+	}
+	return pr.Getwd()
 }
+
+// hostname returns the hostname seen by pr, the real one when there is no prompter
+func hostname(pr Prompter) (string, error) {
+	if pr == nil {
+		return os.Hostname()
+	}
+	return pr.Hostname()
+}
+
+// now returns the current time for pr, the real one when there is no prompter
+func now(pr Prompter) time.Time {
+	if pr == nil {
+		return time.Now()
+	}
+	return pr.Now()
+}
+
+// fsName returns the name in the filesystem of pr of path, relative to its working directory
+func fsName(pr Prompter, path string) (fs.FS, string, error) {
+	fsys := fs.FS(os.DirFS("/"))
+	if pr != nil {
+		fsys = pr.FS()
+	}
+	if !filepath.IsAbs(path) {
+		cwd, err := getwd(pr)
+		if err != nil {
+			return nil, "", err
+		}
+		path = filepath.Join(cwd, path)
+	}
+	name := strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	if name == "" {
+		name = "."
+	}
+	return fsys, name, nil
+}
+
+// readFile reads the file path from the filesystem of pr
+func readFile(pr Prompter, path string) ([]byte, error) {
+	fsys, name, err := fsName(pr, path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(fsys, name)
+}
+
+// stat returns the file info of path in the filesystem of pr
+func stat(pr Prompter, path string) (fs.FileInfo, error) {
+	fsys, name, err := fsName(pr, path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(fsys, name)
+}
+
+// cachedValue returns the value cached for key with cacheValue if it has not expired
+func cachedValue(pr Prompter, key string) (string, bool) {
+	if pr == nil {
+		return "", false
+	}
+	v, ok := pr.GetCache(key)
+	if !ok {
+		return "", false
+	}
+	cached, ok := v.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	expires, _ := cached["expires"].(float64)
+	value, ok := cached["value"].(string)
+	if !ok || now(pr).Unix() >= int64(expires) {
+		return "", false
+	}
+	if r, ok := pr.(CacheReporter); ok {
+		r.CacheHit()
+	}
+	return value, true
+}
+
+// cacheValue caches value for key for ttl. The cache keeps it as json, so it is stored
+// with its expiration as a json object
+func cacheValue(pr Prompter, key, value string, ttl time.Duration) {
+	if pr == nil {
+		return
+	}
+	expires := now(pr).Add(ttl)
+	pr.Cache(key, map[string]interface{}{"expires": float64(expires.Unix()), "value": value})
+}
+
+// bashFormat renders the default theme as bash PS1 escapes, as the plugin tests expect
+var bashFormat = theme.Default().Formatter(theme.Renderer{Target: theme.Bash})
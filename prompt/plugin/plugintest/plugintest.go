@@ -0,0 +1,100 @@
+// Package plugintest provides a plugin.Prompter for testing plugins hermetically: the
+// options, environment, working directory, hostname, clock and files the plugins see are
+// set by the test instead of read from the process, so the tests can run in parallel
+package plugintest
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"testing/fstest"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+// BashFormat renders the default theme as bash PS1 escapes
+var BashFormat = theme.Default().Formatter(theme.Renderer{Target: theme.Bash})
+
+// Prompter is a plugin.Prompter returning the values of its fields. The zero value is
+// ready to use: no options, an empty environment and filesystem, and no working directory
+// or hostname (Getwd and Hostname fail)
+type Prompter struct {
+	Options map[string]interface{}
+	Env     map[string]string
+	Dir     string
+	Host    string
+	// Clock is the time returned by Now
+	Clock time.Time
+	// Files is the filesystem, with the paths relative to / (e.g. "home/user/.kube/config")
+	Files   fstest.MapFS
+	IconSet icon.Set
+
+	mu    sync.Mutex
+	cache map[string]interface{}
+}
+
+// GetOption returns the option key
+func (p *Prompter) GetOption(key string) (interface{}, bool) {
+	value, ok := p.Options[key]
+	return value, ok
+}
+
+// GetCache returns the value cached for key
+func (p *Prompter) GetCache(key string) (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	value, ok := p.cache[key]
+	return value, ok
+}
+
+// Cache caches value for key, in memory
+func (p *Prompter) Cache(key string, value interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cache == nil {
+		p.cache = make(map[string]interface{})
+	}
+	p.cache[key] = value
+	return nil
+}
+
+// Icons returns IconSet
+func (p *Prompter) Icons() icon.Set {
+	return p.IconSet
+}
+
+// Getenv returns the variable key of Env
+func (p *Prompter) Getenv(key string) string {
+	return p.Env[key]
+}
+
+// Getwd returns Dir
+func (p *Prompter) Getwd() (string, error) {
+	if p.Dir == "" {
+		return "", fmt.Errorf("plugintest: no working directory")
+	}
+	return p.Dir, nil
+}
+
+// Hostname returns Host
+func (p *Prompter) Hostname() (string, error) {
+	if p.Host == "" {
+		return "", fmt.Errorf("plugintest: no hostname")
+	}
+	return p.Host, nil
+}
+
+// Now returns Clock
+func (p *Prompter) Now() time.Time {
+	return p.Clock
+}
+
+// FS returns Files
+func (p *Prompter) FS() fs.FS {
+	if p.Files == nil {
+		return fstest.MapFS{}
+	}
+	return p.Files
+}
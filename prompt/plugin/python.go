@@ -1,15 +1,16 @@
 package plugin
 
 import (
-	"os"
 	"strings"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // Python is the plugin struct
 type Python struct {
 	virtualEnv string
+	icons      icon.Set
 }
 
 // Name returns the plugin name
@@ -18,15 +19,17 @@ func (Python) Name() string {
 }
 
 // Help returns help information about this plugin
-func (Python) Help() (description string, options map[string]string) {
+func (Python) Help() (description string, options []Option) {
 	description = "This plugins show the current python virtual environment"
 	return
 }
 
 // Load is the load function of the plugin
-func (p *Python) Load(Prompter) error {
-	virtualEnv, ve := os.LookupEnv("VIRTUAL_ENV")
-	if ve {
+func (p *Python) Load(pr Prompter) error {
+	*p = Python{}
+	p.icons = icons(pr)
+	virtualEnv := getenv(pr, "VIRTUAL_ENV")
+	if virtualEnv != "" {
 		ave := strings.Split(virtualEnv, "/")
 		p.virtualEnv = ave[len(ave)-1]
 	}
@@ -34,9 +37,19 @@ func (p *Python) Load(Prompter) error {
 }
 
 // Get returns the string to use in the prompt
-func (p Python) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (p Python) Get(format theme.Formatter) (string, theme.Role) {
 	if p.virtualEnv != "" {
-		return format(p.virtualEnv, termcolor.FgBlue), []termcolor.Mode{termcolor.FgBlue}
+		return format(p.icons.Prefix("python")+p.virtualEnv, "python.virtualenv"), "python"
+	}
+	return "", ""
+}
+
+// Data returns the plugin state as structured data
+func (p Python) Data() map[string]interface{} {
+	if p.virtualEnv == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"virtualenv": p.virtualEnv,
 	}
-	return "", nil
 }
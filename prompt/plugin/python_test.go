@@ -1,24 +1,24 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestPython(t *testing.T) {
-	os.Setenv("VIRTUAL_ENV", "./Envs/env")
+	t.Parallel()
+	pr := &plugintest.Prompter{Env: map[string]string{"VIRTUAL_ENV": "./Envs/env"}}
 	expected := "\\[\\033[0m\\]\\[\\033[34m\\]env\\[\\033[0m\\]"
 
 	p := &Python{}
-	p.Load(nil)
+	p.Load(pr)
 
 	if p.virtualEnv != "env" {
 		t.Error("Invalid virtualenv")
 	}
 
-	output, _ := p.Get(termcolor.EscapedFormat)
+	output, _ := p.Get(bashFormat)
 	if output != expected {
 		t.Errorf("Expected %s\nGot      %s", expected, output)
 	}
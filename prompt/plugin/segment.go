@@ -0,0 +1,158 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+// ExecTimeout is the default maximum time the command of an exec segment can take
+const ExecTimeout = 500 * time.Millisecond
+
+// Exec is a configured segment showing the first line of the output of a command
+type Exec struct {
+	name    string
+	cmd     string
+	when    Condition
+	ttl     time.Duration
+	timeout time.Duration
+	role    theme.Role
+	output  string
+}
+
+// NewExec returns the exec segment called name, showing the output of cmd (run with sh -c)
+// styled with role if when matches. The output is cached for ttl if it is not zero, and cmd
+// is killed after timeout
+func NewExec(name, cmd string, when Condition, ttl, timeout time.Duration, role theme.Role) *Exec {
+	return &Exec{name: name, cmd: cmd, when: when, ttl: ttl, timeout: timeout, role: role}
+}
+
+// Name returns the plugin name
+func (e *Exec) Name() string {
+	return e.name
+}
+
+// Help returns help information about this plugin
+func (e *Exec) Help() (description string, options []Option) {
+	description = "Shows the output of " + e.cmd
+	return
+}
+
+// Load is the load function of the plugin
+func (e *Exec) Load(pr Prompter) error {
+	e.output = ""
+	cwd, err := getwd(pr)
+	if err != nil {
+		return fmt.Errorf("unable to get current directory: %v", err)
+	}
+	if !e.when.Match(pr, cwd) {
+		return nil
+	}
+	key := "exec-" + e.name + "-" + cwd
+	if e.ttl > 0 {
+		if output, ok := cachedValue(pr, key); ok {
+			e.output = output
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", e.cmd)
+	cmd.Dir = cwd
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s timed out after %v", e.cmd, e.timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to run %s: %v: %s", e.cmd, err, strings.TrimSpace(stderr.String()))
+	}
+	e.output = strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	if e.ttl > 0 {
+		cacheValue(pr, key, e.output, e.ttl)
+	}
+	return nil
+}
+
+// Get returns the string to use in the prompt
+func (e *Exec) Get(format theme.Formatter) (string, theme.Role) {
+	if e.output == "" {
+		return "", e.role
+	}
+	return format(e.output, e.role), e.role
+}
+
+// Data returns the plugin state as structured data
+func (e *Exec) Data() map[string]interface{} {
+	if e.output == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"output": e.output,
+	}
+}
+
+// Env is a configured segment showing the value of an environment variable
+type Env struct {
+	name     string
+	variable string
+	when     Condition
+	role     theme.Role
+	value    string
+}
+
+// NewEnv returns the env segment called name, showing the value of the environment
+// variable styled with role if when matches
+func NewEnv(name, variable string, when Condition, role theme.Role) *Env {
+	return &Env{name: name, variable: variable, when: when, role: role}
+}
+
+// Name returns the plugin name
+func (e *Env) Name() string {
+	return e.name
+}
+
+// Help returns help information about this plugin
+func (e *Env) Help() (description string, options []Option) {
+	description = "Shows the value of $" + e.variable
+	return
+}
+
+// Load is the load function of the plugin
+func (e *Env) Load(pr Prompter) error {
+	e.value = ""
+	cwd, err := getwd(pr)
+	if err != nil {
+		return fmt.Errorf("unable to get current directory: %v", err)
+	}
+	if e.when.Match(pr, cwd) {
+		e.value = getenv(pr, e.variable)
+	}
+	return nil
+}
+
+// Get returns the string to use in the prompt
+func (e *Env) Get(format theme.Formatter) (string, theme.Role) {
+	if e.value == "" {
+		return "", e.role
+	}
+	return format(e.value, e.role), e.role
+}
+
+// Data returns the plugin state as structured data
+func (e *Env) Data() map[string]interface{} {
+	if e.value == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"variable": e.variable,
+		"value":    e.value,
+	}
+}
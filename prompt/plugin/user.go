@@ -2,9 +2,8 @@ package plugin
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // User is the plugin struct
@@ -18,14 +17,15 @@ func (User) Name() string {
 }
 
 // Help returns help information about this plugin
-func (User) Help() (description string, options map[string]string) {
+func (User) Help() (description string, options []Option) {
 	description = "This plugins show the current user if its not root"
 	return
 }
 
 // Load is the load function of the plugin
-func (u *User) Load(Prompter) error {
-	u.user = os.Getenv("USER")
+func (u *User) Load(pr Prompter) error {
+	*u = User{}
+	u.user = getenv(pr, "USER")
 	if u.user == "" {
 		return fmt.Errorf("unable to get USER")
 	}
@@ -33,9 +33,17 @@ func (u *User) Load(Prompter) error {
 }
 
 // Get returns the string to use in the prompt
-func (u User) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (u User) Get(format theme.Formatter) (string, theme.Role) {
 	if u.user == "root" {
-		return "", nil
+		return "", ""
+	}
+	return format(u.user, "user.name"), "user"
+}
+
+// Data returns the plugin state as structured data
+func (u User) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"user": u.user,
+		"root": u.user == "root",
 	}
-	return format(u.user, termcolor.Bold, termcolor.FgGreen), []termcolor.Mode{termcolor.FgGreen}
 }
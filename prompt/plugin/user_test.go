@@ -1,10 +1,9 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestUser(t *testing.T) {
@@ -24,16 +23,18 @@ func TestUser(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
+		tc := tc
 		t.Run(tc.user, func(t *testing.T) {
-			os.Setenv("USER", tc.user)
+			t.Parallel()
+			pr := &plugintest.Prompter{Env: map[string]string{"USER": tc.user}}
 			u := &User{}
-			u.Load(nil)
+			u.Load(pr)
 
 			if u.user != tc.user {
 				t.Error("Invalid user")
 			}
 
-			output, _ := u.Get(termcolor.EscapedFormat)
+			output, _ := u.Get(bashFormat)
 			if output != tc.expected {
 				t.Errorf("Expected %s\nGot      %s", tc.expected, output)
 			}
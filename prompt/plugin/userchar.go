@@ -2,9 +2,8 @@ package plugin
 
 import (
 	"fmt"
-	"os"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
 // UserChar is the plugin struct
@@ -18,14 +17,15 @@ func (UserChar) Name() string {
 }
 
 // Help returns help information about this plugin
-func (UserChar) Help() (description string, options map[string]string) {
+func (UserChar) Help() (description string, options []Option) {
 	description = "This plugins show the typical final char for the prompt (# is the user is root, $ otherwise)"
 	return
 }
 
 // Load is the load function of the plugin
-func (uc *UserChar) Load(Prompter) error {
-	uc.user = os.Getenv("USER")
+func (uc *UserChar) Load(pr Prompter) error {
+	*uc = UserChar{}
+	uc.user = getenv(pr, "USER")
 	if uc.user == "" {
 		return fmt.Errorf("unable to get USER")
 	}
@@ -34,9 +34,16 @@ func (uc *UserChar) Load(Prompter) error {
 }
 
 // Get returns the string to use in the prompt
-func (uc *UserChar) Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode) {
+func (uc *UserChar) Get(format theme.Formatter) (string, theme.Role) {
 	if uc.user == "root" {
-		return "#", nil
+		return "#", ""
+	}
+	return "$", ""
+}
+
+// Data returns the plugin state as structured data
+func (uc *UserChar) Data() map[string]interface{} {
+	return map[string]interface{}{
+		"root": uc.user == "root",
 	}
-	return "$", nil
 }
@@ -1,10 +1,9 @@
 package plugin
 
 import (
-	"os"
 	"testing"
 
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/plugin/plugintest"
 )
 
 func TestUserChar(t *testing.T) {
@@ -24,16 +23,18 @@ func TestUserChar(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
+		tc := tc
 		t.Run(tc.user, func(t *testing.T) {
-			os.Setenv("USER", tc.user)
+			t.Parallel()
+			pr := &plugintest.Prompter{Env: map[string]string{"USER": tc.user}}
 			uc := &UserChar{}
-			uc.Load(nil)
+			uc.Load(pr)
 
 			if uc.user != tc.user {
 				t.Error("Invalid user")
 			}
 
-			output, _ := uc.Get(termcolor.EscapedFormat)
+			output, _ := uc.Get(bashFormat)
 			if output != tc.expected {
 				t.Errorf("Expected %s\nGot      %s", tc.expected, output)
 			}
@@ -0,0 +1,122 @@
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+//powerlineSeparators are the separators by powerline style: the one between segments with
+//different backgrounds and the thin one used between segments sharing the background
+var powerlineSeparators = map[string][2]string{
+	"sharp": {"\ue0b0", "\ue0b1"},
+	"round": {"\ue0b4", "\ue0b5"},
+	"slant": {"\ue0bc", "\ue0bd"},
+}
+
+//PowerlineStyles returns the available powerline separator styles
+func PowerlineStyles() []string {
+	styles := make([]string, 0, len(powerlineSeparators))
+	for s := range powerlineSeparators {
+		styles = append(styles, s)
+	}
+	sort.Strings(styles)
+	return styles
+}
+
+//segment is a plugin output rendered as a powerline block
+type segment struct {
+	text  string
+	style theme.Style
+}
+
+//Powerline renders the plugins as powerline blocks with sharp separators
+func (pr *Prompt) Powerline(plugins ...string) (string, error) {
+	return pr.PowerlineStyle("sharp", plugins...)
+}
+
+//PowerlineStyle renders the plugins as blocks with the background color of their role,
+//joined by separators of style (sharp, round or slant) colored after the neighbouring
+//blocks. Plugins without output are skipped
+func (pr *Prompt) PowerlineStyle(style string, plugins ...string) (string, error) {
+	sep, ok := powerlineSeparators[style]
+	if !ok {
+		return "", fmt.Errorf("unknown powerline style %s, expected one of %s", style, strings.Join(PowerlineStyles(), ", "))
+	}
+
+	segments := make([]segment, 0, len(plugins))
+	for _, name := range plugins {
+		output, err := pr.Load(name)
+		if err != nil {
+			return "", err
+		}
+		if output == "" {
+			continue
+		}
+		block := pr.blockStyle(pr.tmpRole)
+		//a failed plugin shows its error marker, Get would return what its failed Load left
+		if l, failed := pr.failedLoad(name); failed {
+			segments = append(segments, segment{text: l.output, style: block})
+			continue
+		}
+		text, _ := pr.plugins[name].Get(func(text string, role theme.Role) string {
+			text = pr.renderer.Escape(text)
+			if role == "" {
+				return text
+			}
+			s := pr.theme.Style(role)
+			return pr.renderer.RenderOver(text, theme.Style{Bold: s.Bold, Faint: s.Faint, Italic: s.Italic, Underline: s.Underline}, block)
+		})
+		segments = append(segments, segment{text: text, style: block})
+	}
+
+	var b strings.Builder
+	for i, s := range segments {
+		b.WriteString(pr.renderer.Render(" "+s.text+" ", s.style))
+		if i == len(segments)-1 {
+			b.WriteString(pr.renderer.Render(sep[0], theme.Style{Fg: s.style.Bg}))
+			break
+		}
+		next := segments[i+1].style
+		if next.Bg == s.style.Bg {
+			b.WriteString(pr.renderer.Render(sep[1], theme.Style{Fg: s.style.Fg, Bg: s.style.Bg}))
+		} else {
+			b.WriteString(pr.renderer.Render(sep[0], theme.Style{Fg: s.style.Bg, Bg: next.Bg}))
+		}
+	}
+	return b.String(), nil
+}
+
+//blockStyle returns the style of the block of a segment with role: the background is the
+//role background, or its foreground when the role has no background, and the text takes the
+//powerline role colors (the default block text and background)
+func (pr *Prompt) blockStyle(role theme.Role) theme.Style {
+	base := pr.theme.Style("powerline")
+	if role == "" {
+		return base
+	}
+	s := pr.theme.Style(role)
+	block := theme.Style{Fg: base.Fg, Bg: base.Bg, Bold: s.Bold || base.Bold}
+	switch {
+	case s.Bg.IsSet():
+		block.Bg = s.Bg
+		if s.Fg.IsSet() {
+			block.Fg = s.Fg
+		}
+	case s.Fg.IsSet():
+		block.Bg = s.Fg
+	}
+	return block
+}
+
+//failedLoad returns the load of the plugin called name in the template if it failed
+func (pr *Prompt) failedLoad(name string) (loadedPlugin, bool) {
+	for _, l := range pr.loaded {
+		if l.name == name {
+			return l, l.err != nil
+		}
+	}
+	return loadedPlugin{}, false
+}
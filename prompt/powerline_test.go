@@ -0,0 +1,109 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/plugin"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+type fakePlugin struct {
+	name   string
+	output string
+	role   theme.Role
+}
+
+func (f fakePlugin) Name() string                    { return f.name }
+func (f fakePlugin) Help() (string, []plugin.Option) { return "", nil }
+func (f fakePlugin) Load(pr plugin.Prompter) error   { return nil }
+func (f fakePlugin) Get(format theme.Formatter) (string, theme.Role) {
+	if f.output == "" {
+		return "", f.role
+	}
+	return format(f.output, f.role+".x"), f.role
+}
+
+func TestPowerline(t *testing.T) {
+	th := &theme.Theme{Name: "test", Styles: map[theme.Role]theme.Style{
+		"powerline": theme.MustParseStyle("black bg:white"),
+		"a":         theme.MustParseStyle("blue"),
+		"b":         theme.MustParseStyle("blue"),
+		"c":         theme.MustParseStyle("bold yellow bg:red"),
+	}}
+	renderer := theme.Renderer{Target: theme.ANSI}
+	pr := Prompt{
+		plugins: map[string]Plugin{
+			"a":     fakePlugin{name: "a", output: "A", role: "a"},
+			"b":     fakePlugin{name: "b", output: "B", role: "b"},
+			"c":     fakePlugin{name: "c", output: "C", role: "c"},
+			"empty": fakePlugin{name: "empty", role: "a"},
+		},
+		format:   th.Formatter(renderer),
+		theme:    th,
+		renderer: renderer,
+	}
+
+	testCases := []struct {
+		name     string
+		style    string
+		plugins  []string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "thin separator on same background",
+			style:    "sharp",
+			plugins:  []string{"a", "empty", "b"},
+			expected: "\033[0m\033[30;44m \033[0m\033[30;44mA\033[0m\033[30;44m \033[0m" + "\033[0m\033[30;44m\ue0b1\033[0m" + "\033[0m\033[30;44m \033[0m\033[30;44mB\033[0m\033[30;44m \033[0m" + "\033[0m\033[34m\ue0b0\033[0m",
+		},
+		{
+			name:     "separator colored after neighbours",
+			style:    "round",
+			plugins:  []string{"a", "c"},
+			expected: "\033[0m\033[30;44m \033[0m\033[30;44mA\033[0m\033[30;44m \033[0m" + "\033[0m\033[34;41m\ue0b4\033[0m" + "\033[0m\033[1;33;41m \033[0m\033[1;33;41mC\033[0m\033[1;33;41m \033[0m" + "\033[0m\033[31m\ue0b4\033[0m",
+		},
+		{name: "all empty", style: "sharp", plugins: []string{"empty"}, expected: ""},
+		{name: "unknown style", style: "wavy", plugins: []string{"a"}, err: true},
+		{name: "unknown plugin", style: "sharp", plugins: []string{"z"}, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := pr.PowerlineStyle(tc.style, tc.plugins...)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output != tc.expected {
+				t.Errorf("Expected %q\nGot      %q", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestPowerlineFailingPlugin(t *testing.T) {
+	th := &theme.Theme{Name: "test", Styles: map[theme.Role]theme.Style{
+		"powerline": theme.MustParseStyle("black bg:white"),
+		"a":         theme.MustParseStyle("blue"),
+	}}
+	renderer := theme.Renderer{Target: theme.Plain}
+	pr := Prompt{
+		options: resolveOptions(map[string]interface{}{"x.on_error": OnErrorShowMarker}, false),
+		cache:   &Cache{},
+		plugins: map[string]Plugin{
+			"a": fakePlugin{name: "a", output: "A", role: "a"},
+			"x": failingPlugin{fakePlugin{name: "x", output: "X", role: "a"}},
+		},
+		format:   th.Formatter(renderer),
+		theme:    th,
+		renderer: renderer,
+	}
+	expected := " A \ue0b0 ! \ue0b0$ "
+	if output := pr.Compile(`{{powerline "a" "x"}}$ `); output != expected {
+		t.Errorf("Expected %q\nGot      %q", expected, output)
+	}
+}
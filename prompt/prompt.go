@@ -2,36 +2,43 @@ package prompt
 
 import (
 	"bytes"
+	"embed"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"regexp"
 	"text/template"
+	"time"
 
+	"github.com/josledp/goprompt/prompt/icon"
 	"github.com/josledp/goprompt/prompt/plugin"
-	"github.com/josledp/termcolor"
+	"github.com/josledp/goprompt/prompt/theme"
 )
 
-var availablePlugins = []Plugin{
-	&plugin.Aws{},
-	&plugin.Git{},
-	&plugin.LastCommand{},
-	&plugin.Path{},
-	&plugin.Python{},
-	&plugin.User{},
-	&plugin.Hostname{},
-	&plugin.UserChar{},
-	&plugin.Golang{},
-	&plugin.Kubernetes{},
-	&plugin.ExitUserChar{},
+//registeredPlugin is a plugin available to every Prompt: new builds the instance each Prompt loads
+type registeredPlugin struct {
+	name string
+	new  func() Plugin
 }
 
-var defaultTemplates = map[string]string{
-	"Evermeet": `{{load "python" |suffix " "}}{{load "aws"|suffix "|"}}{{load "user"|suffix "@"}}{{load "hostname"}} {{load "lastcommand"|suffix " "}}{{load "path"}}{{load "git"|prefix " "}}{{load "userchar"}} `,
-	"Fedora":   `[ {{load "python"|wrap "(" ") "}}{{load "aws"|suffix "|"}}{{load "user"|suffix "@"}}{{load "hostname"}} {{load "lastcommand"|suffix " "}}{{load "path"}}{{load "git"|prefix " "}} ]{{load "userchar"}} `,
-	"Prefered": `{{load "k8s"}}{{load "python"|wrap "("  ") "}}{{load "aws"|replace "(.*):.*-([^-]*)$" "$1:$2"|suffix "|"}}{{load "path"}}{{load "git"|prefix " "}}{{load "exituserchar"}} `,
+var availablePlugins = []registeredPlugin{
+	{"aws", func() Plugin { return &plugin.Aws{} }},
+	{"git", func() Plugin { return &plugin.Git{} }},
+	{"lastcommand", func() Plugin { return &plugin.LastCommand{} }},
+	{"path", func() Plugin { return &plugin.Path{} }},
+	{"python", func() Plugin { return &plugin.Python{} }},
+	{"user", func() Plugin { return &plugin.User{} }},
+	{"hostname", func() Plugin { return &plugin.Hostname{} }},
+	{"userchar", func() Plugin { return &plugin.UserChar{} }},
+	{"golang", func() Plugin { return &plugin.Golang{} }},
+	{"k8s", func() Plugin { return &plugin.Kubernetes{} }},
+	{"exituserchar", func() Plugin { return &plugin.ExitUserChar{} }},
 }
 
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
 var defaultTemplatesOptions = map[string]map[string]interface{}{
 	"Evermeet": map[string]interface{}{
 		"path.fullpath": float64(1),
@@ -42,6 +49,12 @@ var defaultTemplatesOptions = map[string]map[string]interface{}{
 	"Prefered": map[string]interface{}{
 		"path.fullpath": float64(3),
 	},
+	"Powerline": map[string]interface{}{
+		"path.fullpath": float64(1),
+	},
+	"PowerlineRound": map[string]interface{}{
+		"path.fullpath": float64(3),
+	},
 }
 
 //Prompt is the struct with the prompt options/config
@@ -49,62 +62,135 @@ type Prompt struct {
 	options map[string]interface{}
 	cache   *Cache
 	plugins map[string]Plugin
-	format  func(string, ...termcolor.Mode) string
+	format  theme.Formatter
+
+	//lookPath finds the external plugins executables on demand, see LookupExternalPlugins
+	lookPath func(string) (string, error)
+
+	theme    *theme.Theme
+	renderer theme.Renderer
+	icons    icon.Set
+	loaded   []loadedPlugin
+	failed   []PluginError
+	partials map[string]string
+	getenv   func(string) string
+	getwd    func() (string, error)
+	hostname func() (string, error)
+	clock    func() time.Time
+	fsys     fs.FS
+
+	//rendered and elapsed are the output of the last compiled template and the time it took
+	rendered string
+	elapsed  time.Duration
 
 	debug   bool
-	tmpMode []termcolor.Mode
+	tmpRole theme.Role
 }
 
 //Plugin is the interface all the plugins MUST implement
 type Plugin interface {
 	Name() string
-	Help() (description string, options map[string]string)
+	Help() (description string, options []plugin.Option)
 	Load(pr plugin.Prompter) error
-	Get(format func(string, ...termcolor.Mode) string) (string, []termcolor.Mode)
+	Get(format theme.Formatter) (string, theme.Role)
 }
 
-//New returns a new promp
-func New(options map[string]interface{}, color, debug bool) Prompt {
-	c, err := newCache()
-	if err != nil {
-		log.Printf("unable to initializa cache: %v", err)
-	}
+//New returns a new promp. th is the theme used to style the plugins output, the default one if nil,
+//icons the symbols the plugins draw and target the output the styles are rendered for. The
+//registered plugins are available to its templates, opts customize it further
+func New(options map[string]interface{}, th *theme.Theme, icons icon.Set, target theme.Target, debug bool, opts ...Option) Prompt {
 	// map plugin by name
 	mPlugins := make(map[string]Plugin)
-	for _, p := range availablePlugins {
-		mPlugins[p.Name()] = p
-	}
-	var format func(string, ...termcolor.Mode) string
-
-	if color {
-		shell := detectShell()
-		switch shell {
-		case "bash":
-			format = termcolor.EscapedFormat
-		case "fish":
-			format = termcolor.Format
-		case "zsh":
-			format = termcolor.Format
-
-		default:
-			//Defaut failsafe
-			format = func(s string, modes ...termcolor.Mode) string { return s }
-		}
-	} else {
-		format = func(s string, modes ...termcolor.Mode) string { return s }
+	for _, r := range availablePlugins {
+		mPlugins[r.name] = r.new()
 	}
-
-	return Prompt{
-		options: options,
-		cache:   c,
+	pr := Prompt{
+		options: resolveOptions(options, debug),
 		plugins: mPlugins,
-		format:  format,
+		theme:   th,
+		icons:   icons,
+		getenv:  os.Getenv,
 		debug:   debug,
-		tmpMode: nil,
+		tmpRole: "",
 	}
+	for _, o := range opts {
+		o(&pr)
+	}
+
+	if pr.cache == nil {
+		c, err := newCache()
+		if err != nil {
+			log.Printf("unable to initializa cache: %v", err)
+			c = &Cache{}
+		}
+		pr.cache = c
+	}
+	if pr.theme == nil {
+		pr.theme = theme.Default()
+	}
+
+	//tmux downgrades the colors itself for each client
+	depth := theme.DetectDepth(pr.getenv)
+	if target == theme.Tmux {
+		depth = theme.DepthTrue
+	}
+	pr.renderer = theme.Renderer{Target: target, Depth: depth}
+	if pr.format == nil {
+		pr.format = pr.theme.Formatter(pr.renderer)
+	}
+	return pr
+}
+
+//DetectTarget returns the target to render the styles for the shell running goprompt,
+//or Plain if color is false or the shell is unknown
+func DetectTarget(color bool) theme.Target {
+	if !color {
+		return theme.Plain
+	}
+	return shellTarget(detectShell())
 }
 
-//GetOption returns the option value for key
+//shellTarget returns the target to render the styles for shell, Plain if it is unknown
+func shellTarget(shell string) theme.Target {
+	switch shell {
+	case "bash":
+		return theme.Bash
+	case "fish":
+		return theme.ANSI
+	case "zsh":
+		return theme.ANSI
+	default:
+		//Defaut failsafe
+		return theme.Plain
+	}
+}
+
+//resolveOptions converts the options declared by the plugins to their types, using the
+//declared default for the missing or invalid ones. Unknown options are kept as they are
+func resolveOptions(options map[string]interface{}, debug bool) map[string]interface{} {
+	resolved := make(map[string]interface{}, len(options))
+	for k, v := range options {
+		resolved[k] = v
+	}
+	for name, o := range declaredOptions() {
+		value, ok := options[name]
+		if !ok {
+			resolved[name] = o.Default
+			continue
+		}
+		v, err := o.Convert(value)
+		if err != nil {
+			if debug {
+				fmt.Fprintf(os.Stderr, "option %s: %v, using default %v\n", name, err, o.Default)
+			}
+			v = o.Default
+		}
+		resolved[name] = v
+	}
+	return resolved
+}
+
+//GetOption returns the option value for key, already converted to the type declared by the plugin
 func (pr Prompt) GetOption(key string) (interface{}, bool) {
 	value, ok := pr.options[key]
 	return value, ok
@@ -120,24 +206,89 @@ func (pr Prompt) Cache(key string, value interface{}) error {
 	return pr.cache.set(key, value)
 }
 
-//Compile processes the template and returns a prompt string
-func (pr *Prompt) Compile(tmpl string) string {
+//CacheHit records that the plugin being loaded reused a cached value, see plugin.CacheReporter
+func (pr Prompt) CacheHit() {
+	if pr.cache != nil {
+		pr.cache.hits++
+	}
+}
 
-	t, err := template.New("prompt").Funcs(pr.getFuncMap()).Parse(tmpl)
+//cacheHits returns the number of cached values reused by the plugins so far
+func (pr Prompt) cacheHits() int {
+	if pr.cache == nil {
+		return 0
+	}
+	return pr.cache.hits
+}
+
+//Icons returns the icon set the plugins draw
+func (pr Prompt) Icons() icon.Set {
+	return pr.icons
+}
+
+//FallbackPrompt is the prompt shown after the error marker when the template fails
+const FallbackPrompt = "$ "
+
+//errorMarker is shown when the template fails. goprompt template check reports the error
+const errorMarker = "[template error]"
+
+//Compile processes the template and returns a prompt string. If the template fails the
+//prompt is an error marker followed by FallbackPrompt, so the shell is still usable
+func (pr *Prompt) Compile(tmpl string) string {
+	start := time.Now()
+	output, err := pr.compile(tmpl)
 	if err != nil {
-		log.Fatalf("unable to parse tmpl %s: %v", tmpl, err)
+		if pr.debug {
+			fmt.Fprintf(os.Stderr, "unable to compile template: %v\n", err)
+		}
+		output = pr.format(errorMarker, "status.error") + " " + FallbackPrompt
 	}
+	pr.rendered, pr.elapsed = output, time.Since(start)
+	err = pr.cache.save(pr.Now())
+	if err != nil {
+		log.Printf("Unable to save cache: %v", err)
+	}
+	return output
+}
 
+func (pr *Prompt) compile(tmpl string) (string, error) {
+	pr.loaded = nil
+	pr.failed = nil
+
+	t, broken, err := parseTemplate(tmpl, pr.getFuncMap(), pr.partials)
+	if err != nil {
+		return "", err
+	}
+	if pr.debug {
+		for name, err := range broken {
+			fmt.Fprintf(os.Stderr, "ignoring template %s: %v\n", name, err)
+		}
+	}
 	b := &bytes.Buffer{}
 	err = t.Execute(b, struct{}{})
 	if err != nil {
-		log.Fatalf("unable to execute tmpl %s: %v", tmpl, err)
+		return "", err
 	}
-	err = pr.cache.save()
+	return b.String(), nil
+}
+
+//parseTemplate parses tmpl along with the partials it may include. The partials failing to
+//parse are left out and returned by name, so they only break the templates including them
+func parseTemplate(tmpl string, funcs template.FuncMap, partials map[string]string) (*template.Template, map[string]error, error) {
+	t, err := template.New("prompt").Funcs(funcs).Parse(tmpl)
 	if err != nil {
-		log.Printf("Unable to save cache: %v", err)
+		return nil, nil, err
 	}
-	return b.String()
+	broken := make(map[string]error)
+	for name, partial := range partials {
+		if t.Lookup(name) != nil {
+			continue
+		}
+		if _, err = t.New(name).Parse(partial); err != nil {
+			broken[name] = err
+		}
+	}
+	return t, broken, nil
 }
 
 func (pr *Prompt) getFuncMap() template.FuncMap {
@@ -147,6 +298,21 @@ func (pr *Prompt) getFuncMap() template.FuncMap {
 		"suffix":  pr.Suffix,
 		"prefix":  pr.Prefix,
 		"replace": pr.Replace,
+
+		"powerline":      pr.Powerline,
+		"powerlineStyle": pr.PowerlineStyle,
+
+		"color":    pr.Color,
+		"bold":     pr.Bold,
+		"truncate": Truncate,
+		"ellipsis": Ellipsis,
+		"upper":    Upper,
+		"lower":    Lower,
+		"env":      pr.Getenv,
+		"has":      pr.Has,
+		"default":  Default,
+		"join":     Join,
+		"errors":   pr.ErrorsSegment,
 	}
 }
 
@@ -156,13 +322,38 @@ func (pr *Prompt) Load(plugin string) (string, error) {
 	var output string
 
 	if p, ok = pr.plugins[plugin]; !ok {
-		return "", fmt.Errorf("unable to find plugin: %s", plugin)
+		if p, ok = pr.externalPlugin(plugin); !ok {
+			return "", fmt.Errorf("unable to find plugin: %s", plugin)
+		}
 	}
+	//plugins are loaded once per template, the same plugin can be used by several functions
+	for _, l := range pr.loaded {
+		if l.name == plugin {
+			pr.tmpRole = l.role
+			return l.output, nil
+		}
+	}
+	start, hits := time.Now(), pr.cacheHits()
 	err := p.Load(pr)
 	if err != nil {
-		return "", fmt.Errorf("unable to load plugin %s: %v", plugin, err)
+		pr.failed = append(pr.failed, PluginError{Plugin: plugin, Err: err})
+		pr.tmpRole = "status.error"
+		if pr.debug {
+			fmt.Fprintf(os.Stderr, "plugin %s error: %v\n", plugin, err)
+		}
+		switch pr.errorPolicy(plugin) {
+		case OnErrorFail:
+			return "", fmt.Errorf("unable to load plugin %s: %v", plugin, err)
+		case OnErrorShowMarker:
+			output = pr.format(pr.icons.Icon("error"), "status.error")
+		}
+		pr.loaded = append(pr.loaded, loadedPlugin{name: plugin, plugin: p, output: output, role: pr.tmpRole, err: err,
+			duration: time.Since(start), cached: pr.cacheHits() > hits})
+		return output, nil
 	}
-	output, pr.tmpMode = p.Get(pr.format)
+	output, pr.tmpRole = p.Get(pr.format)
+	pr.loaded = append(pr.loaded, loadedPlugin{name: plugin, plugin: p, output: output, role: pr.tmpRole,
+		duration: time.Since(start), cached: pr.cacheHits() > hits})
 
 	if pr.debug {
 		fmt.Fprintf(os.Stderr, "plugin %s output: %s\n", plugin, output)
@@ -175,10 +366,10 @@ func (pr *Prompt) Wrap(prefix, suffix string, input string) string {
 		return ""
 	}
 	if prefix != "" {
-		prefix = pr.format(prefix, pr.tmpMode...)
+		prefix = pr.format(prefix, pr.tmpRole)
 	}
 	if suffix != "" {
-		suffix = pr.format(suffix, pr.tmpMode...)
+		suffix = pr.format(suffix, pr.tmpRole)
 	}
 	return fmt.Sprintf("%s%s%s", prefix, input, suffix)
 }
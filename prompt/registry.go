@@ -0,0 +1,127 @@
+package prompt
+
+import (
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+//Register makes the plugin name available to every Prompt created afterwards, like the
+//built-in ones: in the templates, the help, the config validation and goprompt template
+//check. f is called by New to build the instance of each Prompt, so the prompts do not
+//share the plugin state. It is meant to be called from the init function of the package
+//implementing the plugin. Register panics if f is nil or if there is already a plugin
+//with the same name
+func Register(name string, f func() Plugin) {
+	if f == nil {
+		panic("prompt: Register plugin factory is nil")
+	}
+	if knownPlugin(name, nil) {
+		panic("prompt: Register called twice for plugin " + name)
+	}
+	availablePlugins = append(availablePlugins, registeredPlugin{name: name, new: f})
+}
+
+//Option customizes a Prompt created by New
+type Option func(*Prompt)
+
+//WithPlugins adds plugins to the Prompt only, replacing the registered ones with the same name
+func WithPlugins(plugins ...Plugin) Option {
+	return func(pr *Prompt) {
+		for _, p := range plugins {
+			pr.plugins[p.Name()] = p
+		}
+	}
+}
+
+//WithCache makes the Prompt use the cache c instead of the default one
+//(/var/tmp/goprompt-$USER). See NewCache
+func WithCache(c *Cache) Option {
+	return func(pr *Prompt) {
+		pr.cache = c
+	}
+}
+
+//WithFormatter makes the Prompt style the plugins output with f instead of the theme
+func WithFormatter(f theme.Formatter) Option {
+	return func(pr *Prompt) {
+		pr.format = f
+	}
+}
+
+//WithEnv makes the Prompt read the environment with getenv instead of os.Getenv
+func WithEnv(getenv func(string) string) Option {
+	return func(pr *Prompt) {
+		pr.getenv = getenv
+	}
+}
+
+//WithCwd makes the plugins of the Prompt see dir as the working directory
+func WithCwd(dir string) Option {
+	return func(pr *Prompt) {
+		pr.getwd = func() (string, error) { return dir, nil }
+	}
+}
+
+//WithHostname makes the plugins of the Prompt see name as the hostname
+func WithHostname(name string) Option {
+	return func(pr *Prompt) {
+		pr.hostname = func() (string, error) { return name, nil }
+	}
+}
+
+//WithClock makes the plugins of the Prompt get the current time from now
+func WithClock(now func() time.Time) Option {
+	return func(pr *Prompt) {
+		pr.clock = now
+	}
+}
+
+//WithFS makes the plugins of the Prompt read the files from fsys, with the paths relative to /
+func WithFS(fsys fs.FS) Option {
+	return func(pr *Prompt) {
+		pr.fsys = fsys
+	}
+}
+
+//Getenv returns the value of the environment variable name, as seen by the plugins
+func (pr Prompt) Getenv(name string) string {
+	if pr.getenv == nil {
+		return os.Getenv(name)
+	}
+	return pr.getenv(name)
+}
+
+//Getwd returns the working directory seen by the plugins
+func (pr Prompt) Getwd() (string, error) {
+	if pr.getwd == nil {
+		return os.Getwd()
+	}
+	return pr.getwd()
+}
+
+//Hostname returns the hostname seen by the plugins
+func (pr Prompt) Hostname() (string, error) {
+	if pr.hostname == nil {
+		return os.Hostname()
+	}
+	return pr.hostname()
+}
+
+//Now returns the current time for the plugins
+func (pr Prompt) Now() time.Time {
+	if pr.clock == nil {
+		return time.Now()
+	}
+	return pr.clock()
+}
+
+//FS returns the filesystem seen by the plugins, with the paths relative to /
+func (pr Prompt) FS() fs.FS {
+	if pr.fsys == nil {
+		return os.DirFS("/")
+	}
+	return pr.fsys
+}
@@ -0,0 +1,93 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestRegister(t *testing.T) {
+	registered := availablePlugins
+	defer func() { availablePlugins = registered }()
+
+	Register("company", func() Plugin { return fakePlugin{name: "company", output: "C", role: "company"} })
+	if !knownPlugin("company", nil) {
+		t.Errorf("expected the registered plugin to be known")
+	}
+	company := func() Plugin { return fakePlugin{name: "company"} }
+	for _, tc := range []struct {
+		name string
+		f    func() Plugin
+	}{
+		{"other", nil},
+		{"company", company},
+		{"git", company},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected Register(%q) to panic", tc.name)
+				}
+			}()
+			Register(tc.name, tc.f)
+		}()
+	}
+
+	c, _ := NewCache("")
+	pr := New(nil, nil, icon.Set{}, theme.Plain, false, WithCache(c))
+	if output := pr.Compile(`{{load "company"}}`); output != "C" {
+		t.Errorf("expected registered plugin output, got %q", output)
+	}
+	other := New(nil, nil, icon.Set{}, theme.Plain, false, WithCache(c))
+	if pr.plugins["git"] == other.plugins["git"] {
+		t.Errorf("expected each prompt to get its own git plugin")
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	c, err := NewCache("")
+	if err != nil {
+		t.Fatalf("unable to create memory cache: %v", err)
+	}
+	env := map[string]string{"TEAM": "infra"}
+	pr := New(nil, nil, icon.Set{}, theme.ANSI, false,
+		WithCache(c),
+		WithPlugins(fakePlugin{name: "a", output: "A", role: "a"}, fakePlugin{name: "path", output: "P", role: "p"}),
+		WithFormatter(func(text string, role theme.Role) string { return "<" + string(role) + ">" + text }),
+		WithEnv(func(name string) string { return env[name] }),
+	)
+	if pr.cache != c {
+		t.Errorf("expected the given cache to be used")
+	}
+	output := pr.Compile(`{{load "a"}} {{load "path"}} {{env "TEAM"}}`)
+	if output != "<a.x>A <p.x>P infra" {
+		t.Errorf("unexpected output %q", output)
+	}
+	if _, ok := pr.plugins["git"]; !ok {
+		t.Errorf("expected the built-in plugins to be kept")
+	}
+}
+
+func TestNewHermetic(t *testing.T) {
+	c, _ := NewCache("")
+	env := map[string]string{"USER": "me", "HOME": "/home/me", "AWS_ROLE": "dev", "AWS_SESSION_EXPIRE": "1000"}
+	files := fstest.MapFS{"home/me/.kube/config": &fstest.MapFile{Data: []byte("current-context: prod\ncontexts:\n- name: prod\n  context:\n    namespace: web\n")}}
+	pr := New(nil, nil, icon.Set{}, theme.Plain, false,
+		WithCache(c),
+		WithEnv(func(name string) string { return env[name] }),
+		WithHostname("box.example.com"),
+		WithCwd("/home/me/src"),
+		WithClock(func() time.Time { return time.Unix(0, 0) }),
+		WithFS(files),
+	)
+	if output := pr.CompileJSON(`{{load "hostname"}} {{load "k8s"}} {{load "aws"}}`); !strings.Contains(output, `"prompt":"box prod(web) dev"`) || !strings.Contains(output, `"expired":false`) {
+		t.Errorf("unexpected output %s", output)
+	}
+	if cwd, _ := pr.Getwd(); cwd != "/home/me/src" {
+		t.Errorf("expected the given working directory, got %s", cwd)
+	}
+}
@@ -0,0 +1,118 @@
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/plugin"
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+//SegmentConfig is a segment declared in the config: an exec or env plugin instance
+type SegmentConfig struct {
+	//Type is exec (shows the first line of the output of Cmd) or env (shows the value of Var)
+	Type string      `json:"type" yaml:"type"`
+	Cmd  string      `json:"cmd,omitempty" yaml:"cmd"`
+	Var  string      `json:"var,omitempty" yaml:"var"`
+	When string      `json:"when,omitempty" yaml:"when"`
+	TTL  interface{} `json:"ttl,omitempty" yaml:"ttl"`
+	//Timeout is the maximum time Cmd can take, 500ms by default
+	Timeout interface{} `json:"timeout,omitempty" yaml:"timeout"`
+	//Color is the theme role or style of the segment, its name by default
+	Color string `json:"color,omitempty" yaml:"color"`
+}
+
+//ttlOption and timeoutOption convert the segments ttl and timeout, like duration plugin options
+var (
+	ttlOption     = plugin.Option{Name: "ttl", Type: plugin.OptionDuration, Default: time.Duration(0)}
+	timeoutOption = plugin.Option{Name: "timeout", Type: plugin.OptionDuration, Default: plugin.ExecTimeout}
+)
+
+//newSegment returns the plugin called name implementing the segment s
+func newSegment(name string, s SegmentConfig) (Plugin, error) {
+	when, err := plugin.ParseCondition(s.When)
+	if err != nil {
+		return nil, err
+	}
+	role := theme.Role(name)
+	if s.Color != "" {
+		role = theme.Role(s.Color)
+	}
+	switch s.Type {
+	case "exec":
+		if s.Cmd == "" {
+			return nil, fmt.Errorf("missing cmd")
+		}
+		ttl := time.Duration(0)
+		if s.TTL != nil {
+			v, err := ttlOption.Convert(s.TTL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid ttl: %v", err)
+			}
+			ttl = v.(time.Duration)
+		}
+		timeout := plugin.ExecTimeout
+		if s.Timeout != nil {
+			v, err := timeoutOption.Convert(s.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout: %v", err)
+			}
+			timeout = v.(time.Duration)
+		}
+		return plugin.NewExec(name, s.Cmd, when, ttl, timeout, role), nil
+	case "env":
+		if s.Var == "" {
+			return nil, fmt.Errorf("missing var")
+		}
+		return plugin.NewEnv(name, s.Var, when, role), nil
+	}
+	return nil, fmt.Errorf("unknown type %q, expecting exec or env", s.Type)
+}
+
+//checkSegments returns the errors of the segments declared in the config, by segment name
+func checkSegments(segments map[string]SegmentConfig) map[string]error {
+	errs := make(map[string]error)
+	for name, s := range segments {
+		if knownPlugin(name, nil) {
+			errs[name] = fmt.Errorf("there is a built-in plugin called %s", name)
+			continue
+		}
+		if _, err := newSegment(name, s); err != nil {
+			errs[name] = err
+		}
+	}
+	return errs
+}
+
+//AddSegments makes the segments declared in the config available to the templates,
+//returning the errors of the invalid ones. Built-in plugins can not be replaced
+func (pr *Prompt) AddSegments(segments map[string]SegmentConfig) []error {
+	invalid := checkSegments(segments)
+	names := make([]string, 0, len(segments))
+	for name := range segments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err, ok := invalid[name]; ok {
+			errs = append(errs, fmt.Errorf("segment %s: %v", name, err))
+			continue
+		}
+		p, _ := newSegment(name, segments[name])
+		pr.plugins[name] = p
+	}
+	return errs
+}
+
+//ConfiguredPlugins returns the plugins available besides the built-in ones, the external
+//plugins (see FindExternalPlugins) and the segments declared in config, by name
+func ConfiguredPlugins(config *Config, path string) map[string]string {
+	plugins := FindExternalPlugins(path, config.GetPlugins())
+	for name, s := range config.GetSegments() {
+		plugins[name] = s.Type + " segment"
+	}
+	return plugins
+}
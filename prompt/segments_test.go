@@ -0,0 +1,70 @@
+package prompt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestSegments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-segments")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, ".terraform"), 0755); err != nil {
+		t.Fatalf("unable to create .terraform: %v", err)
+	}
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(dir)
+	os.Setenv("GOPROMPT_TEST_REGION", "eu-west-1")
+	defer os.Unsetenv("GOPROMPT_TEST_REGION")
+
+	config, err := NewConfig(strings.NewReader(`{"segments": {
+		"tfws": {"type": "exec", "cmd": "echo default; echo ignored", "when": "exists .terraform", "ttl": "30s", "color": "yellow"},
+		"nope": {"type": "exec", "cmd": "echo nope", "when": "exists .missing"},
+		"region": {"type": "env", "var": "GOPROMPT_TEST_REGION"},
+		"slow": {"type": "exec", "cmd": "exec sleep 2", "timeout": "50ms"},
+		"bad": {"type": "shell"},
+		"badtimeout": {"type": "exec", "cmd": "true", "timeout": "soon"}
+	}}`))
+	if err != nil {
+		t.Fatalf("unable to load config: %v", err)
+	}
+	pr := Prompt{
+		cache:   &Cache{},
+		plugins: map[string]Plugin{},
+		format:  theme.Default().Formatter(theme.Renderer{Target: theme.ANSI}),
+		theme:   theme.Default(),
+	}
+	errs := pr.AddSegments(config.GetSegments())
+	if len(errs) != 2 || errs[0].Error() != `segment bad: unknown type "shell", expecting exec or env` ||
+		!strings.HasPrefix(errs[1].Error(), "segment badtimeout: invalid timeout") {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	output := pr.Compile(`{{load "tfws"}}|{{load "nope"}}|{{load "region"}}`)
+	if output != "\033[0m\033[33mdefault\033[0m||eu-west-1" {
+		t.Errorf("unexpected output %q", output)
+	}
+	if v, ok := pr.cache.get("exec-tfws-" + dir); !ok || v.(map[string]interface{})["value"] != "default" {
+		t.Errorf("expected the tfws output to be cached, got %v", v)
+	}
+	//the expired outputs are removed from the cache, whatever directory they were cached for
+	pr.clock = func() time.Time { return time.Now().Add(time.Minute) }
+	pr.Compile(`{{load "region"}}`)
+	if _, ok := pr.cache.get("exec-tfws-" + dir); ok {
+		t.Errorf("expected the expired tfws output to be removed")
+	}
+
+	pr.Compile(`{{load "slow"}}`)
+	if errs := pr.Errors(); len(errs) != 1 || errs[0].Error() != "plugin slow: exec sleep 2 timed out after 50ms" {
+		t.Errorf("expected the slow segment to time out, got %v", errs)
+	}
+}
@@ -0,0 +1,89 @@
+package prompt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+//TemplateExt is the extension of the template files
+const TemplateExt = ".tmpl"
+
+//templateText returns the template in a file. The newline ending the file is not part of the template
+func templateText(data []byte) string {
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+//ReadTemplateFile reads the template in file
+func ReadTemplateFile(file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read template %s: %v", file, err)
+	}
+	return templateText(data), nil
+}
+
+//LoadTemplates returns the templates by name: the predefined ones and the *.tmpl files in dir,
+//named after the file without extension. A file named as a predefined template replaces it.
+//The error is the first file that could not be read, the rest are returned anyway
+func LoadTemplates(dir string) (map[string]string, error) {
+	templates := make(map[string]string)
+	for _, name := range GetDefaultTemplates() {
+		templates[name], _ = GetTemplate(name)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"+TemplateExt))
+	if err != nil {
+		return templates, fmt.Errorf("unable to list templates in %s: %v", dir, err)
+	}
+	//an unreadable file is reported, without leaving out the others
+	var readErr error
+	for _, f := range files {
+		t, err := ReadTemplateFile(f)
+		if err != nil {
+			if readErr == nil {
+				readErr = err
+			}
+			continue
+		}
+		templates[strings.TrimSuffix(filepath.Base(f), TemplateExt)] = t
+	}
+	return templates, readErr
+}
+
+//DefaultTemplate is the template used when none is configured, or the configured one does not exist
+const DefaultTemplate = "Evermeet"
+
+//FindTemplate returns the template called name in templates (see LoadTemplates) and its name.
+//If there is none the default template is returned with an error, so the prompt is never empty
+func FindTemplate(templates map[string]string, name string) (string, string, error) {
+	if t, ok := templates[name]; ok {
+		return name, t, nil
+	}
+	err := fmt.Errorf("template %s not found, using %s", name, DefaultTemplate)
+	if t, ok := templates[DefaultTemplate]; ok {
+		return DefaultTemplate, t, err
+	}
+	return DefaultTemplate, FallbackPrompt, err
+}
+
+//TemplateFilePath returns the path of a template_file: absolute paths and paths in the home
+//directory (~/...) are kept, relative ones are relative to dir
+func TemplateFilePath(file, dir string) string {
+	file = expandHome(file)
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
+
+//AddTemplates makes templates available as partials to the compiled template, so it can
+//include them with {{template "name"}}
+func (pr *Prompt) AddTemplates(templates map[string]string) {
+	if pr.partials == nil {
+		pr.partials = make(map[string]string, len(templates))
+	}
+	for name, t := range templates {
+		pr.partials[name] = t
+	}
+}
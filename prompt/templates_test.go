@@ -0,0 +1,104 @@
+package prompt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/josledp/goprompt/prompt/theme"
+)
+
+func TestTemplates(t *testing.T) {
+	names := GetDefaultTemplates()
+	sort.Strings(names)
+	if strings.Join(names, ",") != "Evermeet,Fedora,Powerline,PowerlineRound,Prefered" {
+		t.Errorf("unexpected default templates %v", names)
+	}
+	evermeet, ok := GetTemplate("Evermeet")
+	if !ok || !strings.HasPrefix(evermeet, `{{load "python" |suffix " "}}`) || !strings.HasSuffix(evermeet, `{{load "userchar"}} `) {
+		t.Errorf("unexpected Evermeet template %q", evermeet)
+	}
+	if _, ok := GetTemplate("Missing"); ok {
+		t.Errorf("expected Missing template not to be found")
+	}
+
+	dir, err := ioutil.TempDir("", "goprompt-templates")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"mine.tmpl":     "{{template \"left\"}}{{template \"sep\" }}{{load \"b\"}}$ \n",
+		"left.tmpl":     "{{load \"a\"}}\n",
+		"Fedora.tmpl":   "fedora\n",
+		"ignored.txt":   "ignored",
+		"partials.tmpl": "{{define \"sep\"}}|{{end}}",
+		"broken.tmpl":   "{{load \"a\"\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+	//a directory can not be read as a template, the files after it are still loaded
+	if err := os.Mkdir(filepath.Join(dir, "a.tmpl"), 0755); err != nil {
+		t.Fatalf("unable to create dir: %v", err)
+	}
+
+	templates, err := LoadTemplates(dir)
+	if err == nil {
+		t.Errorf("expected error reading a.tmpl")
+	}
+	if templates["Fedora"] != "fedora" {
+		t.Errorf("expected Fedora to be replaced, got %q", templates["Fedora"])
+	}
+	if _, ok := templates["Evermeet"]; !ok {
+		t.Errorf("expected predefined templates to be loaded")
+	}
+	if _, ok := templates["ignored"]; ok {
+		t.Errorf("expected non .tmpl files to be ignored")
+	}
+
+	pr := Prompt{
+		cache: &Cache{},
+		plugins: map[string]Plugin{
+			"a": fakePlugin{name: "a", output: "A", role: "a"},
+			"b": fakePlugin{name: "b", output: "B", role: "b"},
+		},
+		format: theme.Default().Formatter(theme.Renderer{Target: theme.Plain}),
+	}
+	pr.AddTemplates(templates)
+	if output := pr.Compile(templates["mine"]); output != "A|B$ " {
+		t.Errorf("Expected %q, got %q", "A|B$ ", output)
+	}
+	if output := pr.Compile(`{{template "broken"}}`); !strings.HasSuffix(output, FallbackPrompt) {
+		t.Errorf("expected the fallback prompt including a broken template, got %q", output)
+	}
+
+	for _, tc := range []struct {
+		name, expected string
+		err            bool
+	}{
+		{name: "mine", expected: "mine"},
+		{name: "Fedora", expected: "Fedora"},
+		{name: "missing", expected: DefaultTemplate, err: true},
+	} {
+		name, tmpl, err := FindTemplate(templates, tc.name)
+		if name != tc.expected || tmpl != templates[tc.expected] || (err != nil) != tc.err {
+			t.Errorf("FindTemplate(%s): expected %s, got %s %q (%v)", tc.name, tc.expected, name, tmpl, err)
+		}
+	}
+	if _, tmpl, _ := FindTemplate(nil, "missing"); tmpl != FallbackPrompt {
+		t.Errorf("expected the fallback prompt without templates, got %q", tmpl)
+	}
+
+	if p := TemplateFilePath("mine.tmpl", dir); p != filepath.Join(dir, "mine.tmpl") {
+		t.Errorf("expected relative template file in the templates dir, got %s", p)
+	}
+	if p := TemplateFilePath("/tmp/x.tmpl", dir); p != "/tmp/x.tmpl" {
+		t.Errorf("expected absolute template file to be kept, got %s", p)
+	}
+}
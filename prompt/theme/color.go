@@ -0,0 +1,252 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Depth is the number of colors a terminal supports
+type Depth int
+
+const (
+	// Depth16 is the 16 basic ANSI colors
+	Depth16 Depth = iota
+	// Depth256 is the xterm 256 color palette
+	Depth256
+	// DepthTrue is 24 bit RGB colors
+	DepthTrue
+)
+
+// DetectDepth guesses the terminal color depth from the COLORTERM and TERM environment variables
+func DetectDepth(getenv func(string) string) Depth {
+	switch strings.ToLower(getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return DepthTrue
+	}
+	term := getenv("TERM")
+	if strings.Contains(term, "truecolor") || strings.Contains(term, "direct") {
+		return DepthTrue
+	}
+	if strings.Contains(term, "256color") {
+		return Depth256
+	}
+	return Depth16
+}
+
+type colorKind uint8
+
+const (
+	colorDefault colorKind = iota
+	colorBasic
+	color256
+	colorRGB
+)
+
+// Color is a terminal color: one of the 16 basic colors, an index of the 256 color palette
+// or a RGB color. The zero value is the terminal default color
+type Color struct {
+	kind    colorKind
+	index   uint8
+	r, g, b uint8
+}
+
+// basicColors are the names of the 16 basic terminal colors by their index
+var basicColors = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"hiblack", "hired", "higreen", "hiyellow", "hiblue", "himagenta", "hicyan", "hiwhite",
+}
+
+// basicRGB is the usual (xterm) RGB value of the 16 basic colors, used to downgrade colors
+var basicRGB = [16][3]uint8{
+	{0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+	{0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+	{0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+	{0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the values of each component in the 6x6x6 cube of the 256 color palette
+var cubeLevels = [6]uint8{0x00, 0x5f, 0x87, 0xaf, 0xd7, 0xff}
+
+// ParseColor parses a color: a basic color name (red, hiblue...), an index of the 256
+// color palette (208) or a hex RGB color (#ff8700). "default" and "none" are the terminal
+// default color
+func ParseColor(s string) (Color, error) {
+	name := strings.ToLower(s)
+	if name == "default" || name == "none" {
+		return Color{}, nil
+	}
+	for i, c := range basicColors {
+		if c == name {
+			return Color{kind: colorBasic, index: uint8(i)}, nil
+		}
+	}
+	if strings.HasPrefix(name, "#") {
+		hex := name[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		v, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return Color{}, fmt.Errorf("invalid rgb color %s", s)
+		}
+		return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+	}
+	if i, err := strconv.ParseUint(name, 10, 8); err == nil {
+		return Color256(uint8(i)), nil
+	}
+	return Color{}, fmt.Errorf("unknown color %s", s)
+}
+
+// Color256 returns the color of the 256 color palette at index
+func Color256(index uint8) Color {
+	return Color{kind: color256, index: index}
+}
+
+// RGB returns a 24 bit color
+func RGB(r, g, b uint8) Color {
+	return Color{kind: colorRGB, r: r, g: g, b: b}
+}
+
+// IsSet returns false for the terminal default color
+func (c Color) IsSet() bool {
+	return c.kind != colorDefault
+}
+
+func (c Color) String() string {
+	switch c.kind {
+	case colorBasic:
+		return basicColors[c.index]
+	case color256:
+		return strconv.Itoa(int(c.index))
+	case colorRGB:
+		return fmt.Sprintf("#%02x%02x%02x", c.r, c.g, c.b)
+	}
+	return "default"
+}
+
+// rgb returns the RGB value of the color
+func (c Color) rgb() (uint8, uint8, uint8) {
+	switch c.kind {
+	case colorBasic:
+		v := basicRGB[c.index]
+		return v[0], v[1], v[2]
+	case color256:
+		switch {
+		case c.index < 16:
+			v := basicRGB[c.index]
+			return v[0], v[1], v[2]
+		case c.index < 232:
+			i := c.index - 16
+			return cubeLevels[i/36], cubeLevels[(i/6)%6], cubeLevels[i%6]
+		default:
+			gray := 8 + 10*(c.index-232)
+			return gray, gray, gray
+		}
+	}
+	return c.r, c.g, c.b
+}
+
+// Downgrade returns the nearest color available at depth
+func (c Color) Downgrade(depth Depth) Color {
+	switch {
+	case c.kind == colorDefault || c.kind == colorBasic:
+		return c
+	case depth >= DepthTrue:
+		return c
+	case depth == Depth256:
+		if c.kind == color256 {
+			return c
+		}
+		return Color256(nearest256(c.rgb()))
+	}
+	if c.kind == color256 && c.index < 16 {
+		return Color{kind: colorBasic, index: c.index}
+	}
+	r, g, b := c.rgb()
+	best, bestDist := 0, -1
+	for i, v := range basicRGB {
+		if d := distance(r, g, b, v[0], v[1], v[2]); bestDist < 0 || d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return Color{kind: colorBasic, index: uint8(best)}
+}
+
+// code returns the SGR code of the color as foreground (or background if bg is true)
+func (c Color) code(bg bool) string {
+	switch c.kind {
+	case color256:
+		if bg {
+			return "48;5;" + strconv.Itoa(int(c.index))
+		}
+		return "38;5;" + strconv.Itoa(int(c.index))
+	case colorRGB:
+		if bg {
+			return fmt.Sprintf("48;2;%d;%d;%d", c.r, c.g, c.b)
+		}
+		return fmt.Sprintf("38;2;%d;%d;%d", c.r, c.g, c.b)
+	}
+	base := 30
+	if c.index >= 8 {
+		base = 90 - 8
+	}
+	if bg {
+		base += 10
+	}
+	return strconv.Itoa(base + int(c.index))
+}
+
+// tmux returns the name of the color for tmux styles
+func (c Color) tmux() string {
+	switch c.kind {
+	case colorBasic:
+		if c.index >= 8 {
+			return "bright" + basicColors[c.index-8]
+		}
+		return basicColors[c.index]
+	case color256:
+		return "colour" + strconv.Itoa(int(c.index))
+	case colorRGB:
+		return c.String()
+	}
+	return "default"
+}
+
+// nearest256 returns the index of the 256 color palette (cube or grays) nearest to a RGB color
+func nearest256(r, g, b uint8) uint8 {
+	level := func(v uint8) int {
+		best, bestDist := 0, -1
+		for i, l := range cubeLevels {
+			d := int(v) - int(l)
+			if d < 0 {
+				d = -d
+			}
+			if bestDist < 0 || d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		return best
+	}
+	ri, gi, bi := level(r), level(g), level(b)
+	cube := uint8(16 + 36*ri + 6*gi + bi)
+	cubeDist := distance(r, g, b, cubeLevels[ri], cubeLevels[gi], cubeLevels[bi])
+
+	avg := (int(r) + int(g) + int(b)) / 3
+	grayIndex := (avg - 8 + 5) / 10
+	if grayIndex < 0 {
+		grayIndex = 0
+	} else if grayIndex > 23 {
+		grayIndex = 23
+	}
+	gray := uint8(8 + 10*grayIndex)
+	if distance(r, g, b, gray, gray, gray) < cubeDist {
+		return uint8(232 + grayIndex)
+	}
+	return cube
+}
+
+func distance(r1, g1, b1, r2, g2, b2 uint8) int {
+	dr, dg, db := int(r1)-int(r2), int(g1)-int(g2), int(b1)-int(b2)
+	return dr*dr + dg*dg + db*db
+}
@@ -0,0 +1,192 @@
+package theme
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Style is how a text is rendered: its colors and attributes
+type Style struct {
+	Fg        Color
+	Bg        Color
+	Bold      bool
+	Faint     bool
+	Italic    bool
+	Underline bool
+}
+
+// ParseStyle parses a style spec: a space separated list of attributes (bold, faint, italic,
+// underline) and colors (see ParseColor). A bare color or fg:color sets the foreground,
+// bg:color the background. E.g. "bold magenta", "fg:black bg:cyan", "#ff8700 bg:236"
+func ParseStyle(spec string) (Style, error) {
+	var s Style
+	for _, token := range strings.Fields(spec) {
+		var err error
+		switch t := strings.ToLower(token); {
+		case t == "bold":
+			s.Bold = true
+		case t == "faint":
+			s.Faint = true
+		case t == "italic":
+			s.Italic = true
+		case t == "underline":
+			s.Underline = true
+		case strings.HasPrefix(t, "bg:"):
+			s.Bg, err = ParseColor(t[3:])
+		case strings.HasPrefix(t, "fg:"):
+			s.Fg, err = ParseColor(t[3:])
+		default:
+			s.Fg, err = ParseColor(t)
+		}
+		if err != nil {
+			return Style{}, fmt.Errorf("invalid style %q: %v", spec, err)
+		}
+	}
+	return s, nil
+}
+
+// MustParseStyle is like ParseStyle but panics on invalid specs. Meant for built-in styles
+func MustParseStyle(spec string) Style {
+	s, err := ParseStyle(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// IsZero returns true if the style does not change the text
+func (s Style) IsZero() bool {
+	return s == Style{}
+}
+
+func (s Style) String() string {
+	var tokens []string
+	for _, a := range []struct {
+		set  bool
+		name string
+	}{{s.Bold, "bold"}, {s.Faint, "faint"}, {s.Italic, "italic"}, {s.Underline, "underline"}} {
+		if a.set {
+			tokens = append(tokens, a.name)
+		}
+	}
+	if s.Fg.IsSet() {
+		tokens = append(tokens, s.Fg.String())
+	}
+	if s.Bg.IsSet() {
+		tokens = append(tokens, "bg:"+s.Bg.String())
+	}
+	return strings.Join(tokens, " ")
+}
+
+// codes returns the SGR codes of the style, with its colors downgraded to depth
+func (s Style) codes(depth Depth) []string {
+	var codes []string
+	if s.Bold {
+		codes = append(codes, "1")
+	}
+	if s.Faint {
+		codes = append(codes, "2")
+	}
+	if s.Italic {
+		codes = append(codes, "3")
+	}
+	if s.Underline {
+		codes = append(codes, "4")
+	}
+	if s.Fg.IsSet() {
+		codes = append(codes, s.Fg.Downgrade(depth).code(false))
+	}
+	if s.Bg.IsSet() {
+		codes = append(codes, s.Bg.Downgrade(depth).code(true))
+	}
+	return codes
+}
+
+// tmux returns the tmux style attributes of the style
+func (s Style) tmux() []string {
+	attrs := []string{"default"}
+	if s.Fg.IsSet() {
+		attrs = append(attrs, "fg="+s.Fg.tmux())
+	}
+	if s.Bg.IsSet() {
+		attrs = append(attrs, "bg="+s.Bg.tmux())
+	}
+	for _, a := range []struct {
+		set  bool
+		name string
+	}{{s.Bold, "bold"}, {s.Faint, "dim"}, {s.Italic, "italics"}, {s.Underline, "underscore"}} {
+		if a.set {
+			attrs = append(attrs, a.name)
+		}
+	}
+	return attrs
+}
+
+// Target is the kind of output styles are rendered for
+type Target string
+
+const (
+	// Plain renders the text without any style
+	Plain Target = "plain"
+	// ANSI renders the styles as raw ANSI escape sequences (zsh, fish, terminals)
+	ANSI Target = "ansi"
+	// Bash renders the styles as ANSI escape sequences enclosed in \[ \] for PS1
+	Bash Target = "bash"
+	// Tmux renders the styles as tmux #[fg=...,bg=...] markup, for the status line
+	Tmux Target = "tmux"
+)
+
+// Renderer renders styles for an output target with the colors its terminal supports
+type Renderer struct {
+	Target Target
+	Depth  Depth
+}
+
+// Escape returns text so the target shows it as is: tmux expands #{...}, #H and the like
+// in the status line, so # is doubled for it
+func (r Renderer) Escape(text string) string {
+	if r.Target == Tmux {
+		return strings.Replace(text, "#", "##", -1)
+	}
+	return text
+}
+
+// Render returns text with the style s applied
+func (r Renderer) Render(text string, s Style) string {
+	if s.IsZero() || r.Target == Plain || r.Target == "" {
+		return text
+	}
+	return r.sequence(s) + text + r.sequence(Style{})
+}
+
+// RenderOver returns text with the style s applied on top of base, restoring base afterwards
+// instead of resetting the style, so text can be embedded in a text rendered with base.
+// The colors not set in s are taken from base
+func (r Renderer) RenderOver(text string, s, base Style) string {
+	if r.Target == Plain || r.Target == "" {
+		return text
+	}
+	if !s.Fg.IsSet() {
+		s.Fg = base.Fg
+	}
+	if !s.Bg.IsSet() {
+		s.Bg = base.Bg
+	}
+	return r.sequence(s) + text + r.sequence(base)
+}
+
+// sequence returns the markup resetting the style and applying s
+func (r Renderer) sequence(s Style) string {
+	if r.Target == Tmux {
+		return "#[" + strings.Join(s.tmux(), ",") + "]"
+	}
+	start, end := "\033[", "m"
+	if r.Target == Bash {
+		start, end = "\\[\\033[", "m\\]"
+	}
+	reset := start + "0" + end
+	if s.IsZero() {
+		return reset
+	}
+	return reset + start + strings.Join(s.codes(r.Depth), ";") + end
+}
@@ -0,0 +1,286 @@
+// Package theme maps the semantic roles plugins use to style their output
+// (git.branch, path.dir, status.error...) to colors and attributes
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Role is the semantic name of a styled element, e.g. "git.branch". When a theme has
+// no style for a role it falls back to its parent role ("git")
+type Role string
+
+// Formatter renders text with the style the theme assigns to role. A role unknown to the
+// theme that is a valid style spec (e.g. "#ff8700", "bold 208") is rendered with that style
+type Formatter func(text string, role Role) string
+
+// Theme is a named set of styles by role
+type Theme struct {
+	Name   string
+	Styles map[Role]Style
+}
+
+// file is the format of the theme files
+type file struct {
+	Extends string            `json:"extends" yaml:"extends"`
+	Styles  map[string]string `json:"styles" yaml:"styles"`
+}
+
+// builtin are the themes shipped with goprompt, as style specs by role
+var builtin = map[string]map[Role]string{
+	"dark": {
+		"git":            "magenta",
+		"git.conflicted": "red",
+		"git.staged":     "cyan",
+		"git.changed":    "cyan",
+		"git.submodules": "cyan",
+		"git.untracked":  "cyan",
+		"git.clean":      "higreen",
+		"git.stashed":    "himagenta",
+		"path":           "blue",
+		"path.dir":       "bold blue",
+		"python":         "blue",
+		"golang":         "blue",
+		"k8s":            "hiblue",
+		"aws.valid":      "green",
+		"aws.warning":    "blue",
+		"aws.expiring":   "yellow",
+		"aws.expired":    "red",
+		"user":           "green",
+		"user.name":      "bold green",
+		"hostname":       "green",
+		"hostname.name":  "bold green",
+		"hostname.root":  "bold red",
+		"lastcommand":    "hiyellow",
+		"status.error":   "hired",
+		"powerline":      "black bg:white",
+	},
+	"light": {
+		"git":            "magenta",
+		"git.conflicted": "red",
+		"git.staged":     "blue",
+		"git.changed":    "blue",
+		"git.submodules": "blue",
+		"git.untracked":  "blue",
+		"git.clean":      "green",
+		"git.stashed":    "magenta",
+		"path":           "blue",
+		"path.dir":       "bold blue",
+		"python":         "blue",
+		"golang":         "blue",
+		"k8s":            "blue",
+		"aws.valid":      "green",
+		"aws.warning":    "blue",
+		"aws.expiring":   "magenta",
+		"aws.expired":    "red",
+		"user":           "green",
+		"user.name":      "bold green",
+		"hostname":       "green",
+		"hostname.name":  "bold green",
+		"hostname.root":  "bold red",
+		"lastcommand":    "bold red",
+		"status.error":   "bold red",
+		"powerline":      "hiwhite bg:hiblack",
+	},
+	"solarized": {
+		"git":            "magenta",
+		"git.conflicted": "red",
+		"git.staged":     "cyan",
+		"git.changed":    "yellow",
+		"git.submodules": "yellow",
+		"git.untracked":  "hiblue",
+		"git.clean":      "green",
+		"git.stashed":    "hicyan",
+		"path":           "blue",
+		"path.dir":       "bold blue",
+		"python":         "cyan",
+		"golang":         "cyan",
+		"k8s":            "blue",
+		"aws.valid":      "green",
+		"aws.warning":    "blue",
+		"aws.expiring":   "yellow",
+		"aws.expired":    "red",
+		"user":           "yellow",
+		"user.name":      "bold yellow",
+		"hostname":       "yellow",
+		"hostname.name":  "bold yellow",
+		"hostname.root":  "bold red",
+		"lastcommand":    "hired",
+		"status.error":   "red",
+		"powerline":      "black bg:white",
+	},
+	"high-contrast": {
+		"git":            "bold hiwhite",
+		"git.conflicted": "bold hired",
+		"git.staged":     "bold hiyellow",
+		"git.changed":    "bold hiyellow",
+		"git.submodules": "bold hiyellow",
+		"git.untracked":  "bold hiyellow",
+		"git.clean":      "bold higreen",
+		"git.stashed":    "bold hicyan",
+		"path":           "bold hiwhite",
+		"path.dir":       "bold hiwhite",
+		"python":         "bold hicyan",
+		"golang":         "bold hicyan",
+		"k8s":            "bold hicyan",
+		"aws.valid":      "bold higreen",
+		"aws.warning":    "bold hicyan",
+		"aws.expiring":   "bold hiyellow",
+		"aws.expired":    "bold hiwhite bg:red",
+		"user":           "bold higreen",
+		"user.name":      "bold higreen",
+		"hostname":       "bold higreen",
+		"hostname.name":  "bold higreen",
+		"hostname.root":  "bold hiwhite bg:red",
+		"lastcommand":    "bold hiyellow",
+		"status.error":   "bold hiwhite bg:red",
+		"powerline":      "bold black bg:hiwhite",
+	},
+}
+
+// DefaultName is the name of the default theme
+const DefaultName = "dark"
+
+// Names returns the names of the built-in themes
+func Names() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the default theme
+func Default() *Theme {
+	t, _ := Builtin(DefaultName)
+	return t
+}
+
+// Builtin returns a built-in theme by its name
+func Builtin(name string) (*Theme, bool) {
+	specs, ok := builtin[name]
+	if !ok {
+		return nil, false
+	}
+	t := &Theme{Name: name, Styles: make(map[Role]Style, len(specs))}
+	for role, spec := range specs {
+		t.Styles[role] = MustParseStyle(spec)
+	}
+	return t, true
+}
+
+// Load returns the theme called name: a built-in theme, a theme file <name>.json or
+// <name>.yaml in any of dirs, or the theme file at path name. Theme files contain
+// style specs by role ({"styles": {"git.branch": "bold magenta"}}) and may extend
+// another theme ({"extends": "dark"}), the default one if not set
+func Load(name string, dirs ...string) (*Theme, error) {
+	return load(name, dirs, map[string]bool{})
+}
+
+func load(name string, dirs []string, seen map[string]bool) (*Theme, error) {
+	if t, ok := Builtin(name); ok {
+		return t, nil
+	}
+	if seen[name] {
+		return nil, fmt.Errorf("theme %s extends itself", name)
+	}
+	seen[name] = true
+
+	path := findFile(name, dirs)
+	if path == "" {
+		return nil, fmt.Errorf("theme %s not found", name)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read theme %s: %v", path, err)
+	}
+	var f file
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &f)
+	default:
+		err = json.Unmarshal(data, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal theme %s: %v", path, err)
+	}
+
+	base := f.Extends
+	if base == "" {
+		base = DefaultName
+	}
+	parent, err := load(base, dirs, seen)
+	if err != nil {
+		return nil, err
+	}
+	t := &Theme{Name: name, Styles: make(map[Role]Style, len(parent.Styles)+len(f.Styles))}
+	for role, s := range parent.Styles {
+		t.Styles[role] = s
+	}
+	for role, spec := range f.Styles {
+		s, err := ParseStyle(spec)
+		if err != nil {
+			return nil, fmt.Errorf("theme %s, role %s: %v", path, role, err)
+		}
+		t.Styles[Role(role)] = s
+	}
+	return t, nil
+}
+
+func findFile(name string, dirs []string) string {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+		return ""
+	}
+	for _, dir := range dirs {
+		for _, ext := range []string{".json", ".yaml", ".yml"} {
+			path := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// Style returns the style for role, falling back to its parent roles, or to role
+// parsed as a style spec
+func (t *Theme) Style(role Role) Style {
+	r := string(role)
+	for r != "" {
+		if s, ok := t.Styles[Role(r)]; ok {
+			return s
+		}
+		i := strings.LastIndex(r, ".")
+		if i < 0 {
+			break
+		}
+		r = r[:i]
+	}
+	if s, err := ParseStyle(string(role)); err == nil {
+		return s
+	}
+	return Style{}
+}
+
+// Formatter returns a Formatter rendering the theme styles with r
+func (t *Theme) Formatter(r Renderer) Formatter {
+	return func(text string, role Role) string {
+		text = r.Escape(text)
+		if role == "" {
+			return text
+		}
+		return r.Render(text, t.Style(role))
+	}
+}
@@ -0,0 +1,165 @@
+package theme
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseStyle(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		expected string
+		err      bool
+	}{
+		{spec: "bold blue", expected: "\\[\\033[0m\\]\\[\\033[1;34m\\]x\\[\\033[0m\\]"},
+		{spec: "hiyellow", expected: "\\[\\033[0m\\]\\[\\033[93m\\]x\\[\\033[0m\\]"},
+		{spec: "underline fg:black bg:hicyan", expected: "\\[\\033[0m\\]\\[\\033[4;30;106m\\]x\\[\\033[0m\\]"},
+		{spec: "", expected: "x"},
+		{spec: "208 bg:#303030", expected: "\\[\\033[0m\\]\\[\\033[33;40m\\]x\\[\\033[0m\\]"},
+		{spec: "bold pink", err: true},
+		{spec: "#12345", err: true},
+		{spec: "256", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := ParseStyle(tc.spec)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error parsing %s", tc.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if output := (Renderer{Target: Bash}).Render("x", s); output != tc.expected {
+				t.Errorf("Expected %s\nGot      %s", tc.expected, output)
+			}
+			if output := (Renderer{Target: Plain, Depth: DepthTrue}).Render("x", s); output != "x" {
+				t.Errorf("Expected plain x, got %s", output)
+			}
+		})
+	}
+}
+
+func TestColorDepth(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		depth    Depth
+		expected string
+	}{
+		{spec: "#ff8700", depth: DepthTrue, expected: "\033[0m\033[38;2;255;135;0m x\033[0m"},
+		{spec: "#ff8700", depth: Depth256, expected: "\033[0m\033[38;5;208m x\033[0m"},
+		{spec: "#ff8700", depth: Depth16, expected: "\033[0m\033[33m x\033[0m"},
+		{spec: "bg:#808080", depth: Depth256, expected: "\033[0m\033[48;5;244m x\033[0m"},
+		{spec: "208", depth: DepthTrue, expected: "\033[0m\033[38;5;208m x\033[0m"},
+		{spec: "9", depth: Depth16, expected: "\033[0m\033[91m x\033[0m"},
+		{spec: "bold blue", depth: Depth16, expected: "\033[0m\033[1;34m x\033[0m"},
+	}
+
+	for _, tc := range testCases {
+		s := MustParseStyle(tc.spec)
+		if output := (Renderer{Target: ANSI, Depth: tc.depth}).Render(" x", s); output != tc.expected {
+			t.Errorf("%s at depth %d: expected %q, got %q", tc.spec, tc.depth, tc.expected, output)
+		}
+	}
+
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	if d := DetectDepth(env(map[string]string{"COLORTERM": "truecolor", "TERM": "xterm"})); d != DepthTrue {
+		t.Errorf("expected truecolor depth, got %d", d)
+	}
+	if d := DetectDepth(env(map[string]string{"TERM": "screen-256color"})); d != Depth256 {
+		t.Errorf("expected 256 color depth, got %d", d)
+	}
+	if d := DetectDepth(env(map[string]string{"TERM": "linux"})); d != Depth16 {
+		t.Errorf("expected 16 color depth, got %d", d)
+	}
+}
+
+func TestTmux(t *testing.T) {
+	r := Renderer{Target: Tmux, Depth: DepthTrue}
+	testCases := []struct {
+		spec     string
+		expected string
+	}{
+		{spec: "bold hired", expected: "#[default,fg=brightred,bold]x#[default]"},
+		{spec: "208 bg:#303030", expected: "#[default,fg=colour208,bg=#303030]x#[default]"},
+		{spec: "italic underline faint", expected: "#[default,dim,italics,underscore]x#[default]"},
+		{spec: "", expected: "x"},
+	}
+	for _, tc := range testCases {
+		if output := r.Render("x", MustParseStyle(tc.spec)); output != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.spec, tc.expected, output)
+		}
+	}
+
+	base := MustParseStyle("black bg:blue")
+	output := r.RenderOver("x", MustParseStyle("bold"), base)
+	if expected := "#[default,fg=black,bg=blue,bold]x#[default,fg=black,bg=blue]"; output != expected {
+		t.Errorf("expected %s, got %s", expected, output)
+	}
+
+	//the text is escaped, tmux would expand #H to the hostname
+	th := &Theme{Styles: map[Role]Style{"git": MustParseStyle("red")}}
+	format := th.Formatter(r)
+	if output := format("fix-#H", "git"); output != "#[default,fg=red]fix-##H#[default]" {
+		t.Errorf("expected the styled text escaped, got %s", output)
+	}
+	if output := format("#{pane_id}", ""); output != "##{pane_id}" {
+		t.Errorf("expected the text escaped, got %s", output)
+	}
+	if output := (&Theme{}).Formatter(Renderer{Target: ANSI})("#H", ""); output != "#H" {
+		t.Errorf("expected the text kept for other targets, got %s", output)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goprompt-theme")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "mine.yaml"), []byte("extends: light\nstyles:\n  git.branch: bold cyan\n"), 0644)
+	if err != nil {
+		t.Fatalf("unable to write theme: %v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "loop.json"), []byte(`{"extends":"loop"}`), 0644)
+	if err != nil {
+		t.Fatalf("unable to write theme: %v", err)
+	}
+
+	th, err := Load("mine", dir)
+	if err != nil {
+		t.Fatalf("unable to load theme: %v", err)
+	}
+	if s := th.Style("git.branch").String(); s != "bold cyan" {
+		t.Errorf("expected git.branch bold cyan, got %s", s)
+	}
+	if s := th.Style("git.branch.unknown").String(); s != "bold cyan" {
+		t.Errorf("expected fallback to git.branch, got %s", s)
+	}
+	if s := th.Style("status.error").String(); s != "bold red" {
+		t.Errorf("expected status.error from light theme, got %s", s)
+	}
+	if s := th.Style("unknown"); !s.IsZero() {
+		t.Errorf("expected empty style for unknown role, got %s", s)
+	}
+
+	if _, err := Load("loop", dir); err == nil {
+		t.Errorf("expected error loading a theme extending itself")
+	}
+	if _, err := Load("missing", dir); err == nil {
+		t.Errorf("expected error loading a missing theme")
+	}
+	for _, name := range Names() {
+		if _, err := Load(name); err != nil {
+			t.Errorf("unable to load builtin theme %s: %v", name, err)
+		}
+	}
+}
@@ -3,16 +3,23 @@ package prompt
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/josledp/goprompt/prompt/plugin"
 )
 
 //GetDefaultTemplates returns the default templates defined by the prompt package
 func GetDefaultTemplates() []string {
 	templates := make([]string, 0)
-	for name := range defaultTemplates {
-		templates = append(templates, name)
+	files, _ := fs.Glob(builtinTemplates, "templates/*"+TemplateExt)
+	for _, f := range files {
+		templates = append(templates, strings.TrimSuffix(path.Base(f), TemplateExt))
 	}
 	return templates
 }
@@ -25,27 +32,69 @@ func GetTemplateOptions(template string) (map[string]interface{}, bool) {
 
 //GetTemplate returns the a default template by its name
 func GetTemplate(template string) (string, bool) {
-	t, ok := defaultTemplates[template]
-	return t, ok
+	data, err := builtinTemplates.ReadFile("templates/" + template + TemplateExt)
+	if err != nil {
+		return "", false
+	}
+	return templateText(data), true
 }
 
-//ShowHelpPlugin writes on w the plugin help
-func ShowHelpPlugin(w io.Writer) {
+//ShowHelpPlugin writes on w the plugin help: the available plugins and, if config is not
+//nil, the segments it declares and the external plugins
+func ShowHelpPlugin(w io.Writer, config *Config) {
 	fmt.Fprintf(w, "Plugin help\n")
 	fmt.Fprintf(w, "===============\n")
-	for _, p := range availablePlugins {
-		name := p.Name()
-		desc, opt := p.Help()
-		fmt.Fprintf(w, "Plugin: %s\n", name)
-		fmt.Fprintf(w, "Description: %s\n", desc)
-		if len(opt) > 0 {
-			fmt.Fprintf(w, "Options:\n")
-			for o, od := range opt {
-				fmt.Fprintf(w, "  %s: %s\n", o, od)
+	for _, r := range availablePlugins {
+		showHelp(w, r.name, r.new())
+	}
+	if config == nil {
+		return
+	}
+	segments := config.GetSegments()
+	invalid := checkSegments(segments)
+	external := FindExternalPlugins(os.Getenv("PATH"), config.GetPlugins())
+	names := make([]string, 0, len(segments)+len(external))
+	for name := range segments {
+		names = append(names, name)
+	}
+	for name := range external {
+		if _, ok := segments[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if s, ok := segments[name]; ok {
+			if _, ok := invalid[name]; !ok {
+				p, _ := newSegment(name, s)
+				showHelp(w, name, p)
+			}
+			continue
+		}
+		showHelp(w, name, plugin.NewExternal(name, external[name], nil))
+	}
+}
+
+//showHelp writes on w the help of the plugin p called name
+func showHelp(w io.Writer, name string, p Plugin) {
+	desc, opt := p.Help()
+	opt = append(opt, errorPolicyOption(name))
+	fmt.Fprintf(w, "Plugin: %s\n", name)
+	fmt.Fprintf(w, "Description: %s\n", desc)
+	if len(opt) > 0 {
+		fmt.Fprintf(w, "Options:\n")
+		for _, o := range opt {
+			fmt.Fprintf(w, "  %s (%s, default %v): %s\n", o.Name, o.Type, o.Default, o.Description)
+			if len(o.Allowed) > 0 {
+				allowed := make([]string, 0, len(o.Allowed))
+				for _, a := range o.Allowed {
+					allowed = append(allowed, fmt.Sprint(a))
+				}
+				fmt.Fprintf(w, "    allowed values: %s\n", strings.Join(allowed, ", "))
 			}
 		}
-		fmt.Fprintf(w, "\n")
 	}
+	fmt.Fprintf(w, "\n")
 }
 
 //ShowHelpTemplate writes on w the templating help
@@ -53,9 +102,21 @@ func ShowHelpTemplate(w io.Writer) {
 	fmt.Fprintf(w, "Templating help\n")
 	fmt.Fprintf(w, "===============\n")
 	fmt.Fprintln(w,
-		`This project uses gotemplate. There are 4 functions over what gotemplate can do:
+		`This project uses gotemplate. These are the functions over what gotemplate can do:
 		load "plugin": will load plugin
-		prefix, suffix, wrap: will add text/symbols before, after or both to any plugin output if it has content`)
+		has "plugin": true if the plugin has any output, e.g. {{if has "git"}}...{{end}}
+		prefix, suffix, wrap: will add text/symbols before, after or both to any plugin output if it has content
+		replace "regexp" "replacement": will replace the matches of regexp
+		color "style": will render the text with a theme role (git.branch) or a style (bold red, #ff8700)
+		bold: will render the text in bold
+		truncate n, ellipsis n: will keep the first n characters (ellipsis ends the shortened text with …)
+		upper, lower: will change the text case
+		env "VAR": will return the value of the environment variable VAR
+		default "value": will return value if the text is empty
+		errors: will show an error marker if any plugin loaded before it failed (see the on_error plugin options)
+		join "sep" text...: will join the non empty texts with sep, e.g. {{join " " (load "k8s") (load "aws")}}
+		powerline "plugin"...: will load the plugins and draw them as powerline blocks, skipping the empty ones
+		powerlineStyle "style" "plugin"...: like powerline with another separator style (`+strings.Join(PowerlineStyles(), ", ")+`)`)
 }
 
 func detectShell() string {
@@ -0,0 +1,306 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/josledp/goprompt/prompt/icon"
+	"github.com/josledp/goprompt/prompt/plugin"
+)
+
+//configKeys are the known top level config keys
+var configKeys = map[string]struct{}{
+	"template":        struct{}{},
+	"custom_template": struct{}{},
+	"template_file":   struct{}{},
+	"options":         struct{}{},
+	"theme":           struct{}{},
+	"icons":           struct{}{},
+	"trusted_dirs":    struct{}{},
+	"plugins":         struct{}{},
+	"segments":        struct{}{},
+}
+
+//ValidationError is a problem found validating a config file
+type ValidationError struct {
+	File string
+	Line int
+	Key  string
+	Msg  string
+}
+
+func (e ValidationError) Error() string {
+	pos := e.File
+	if pos == "" {
+		pos = "config"
+	}
+	if e.Line > 0 {
+		pos = fmt.Sprintf("%s:%d", pos, e.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", pos, e.Key, e.Msg)
+}
+
+//Validate checks every config file against the options declared by the plugins,
+//reporting unknown keys and values with the wrong type
+func (c *Config) Validate() []ValidationError {
+	declared := declaredOptions()
+	external := ConfiguredPlugins(c, os.Getenv("PATH"))
+	for name, command := range external {
+		declared[name+".on_error"] = errorPolicyOption(name)
+		if _, ok := c.params.Segments[name]; ok {
+			continue
+		}
+		_, opts := plugin.NewExternal(name, command, nil).Help()
+		for _, o := range opts {
+			declared[o.Name] = o
+		}
+	}
+	layers := c.layers
+	if len(layers) == 0 {
+		layers = []configLayer{{params: c.params}}
+	}
+
+	var errs []ValidationError
+	for _, l := range layers {
+		first := len(errs)
+		format, ok := configFormats[filepath.Ext(l.source)]
+		if !ok {
+			format = "json"
+		}
+		lines := keyLines(format, l.data)
+		if l.data != nil {
+			raw, err := unmarshalRaw(format, l.data)
+			if err == nil {
+				for _, k := range sortedKeys(raw) {
+					if _, ok := configKeys[k]; !ok {
+						errs = append(errs, ValidationError{File: l.source, Line: lines[k], Key: k, Msg: "unknown config key"})
+					}
+				}
+			}
+		}
+		if l.params.Icons != "" {
+			if _, err := icon.Load(l.params.Icons); err != nil {
+				errs = append(errs, ValidationError{File: l.source, Line: lines["icons"], Key: "icons", Msg: err.Error()})
+			}
+		}
+		segmentErrs := checkSegments(l.params.Segments)
+		for _, name := range sortedSegments(segmentErrs) {
+			errs = append(errs, ValidationError{File: l.source, Line: lines["segments."+name], Key: "segments." + name, Msg: segmentErrs[name].Error()})
+		}
+		for _, k := range sortedKeys(l.params.Options) {
+			o, ok := declared[k]
+			//the external plugins accept any other option, sent to their command as is
+			if i := strings.Index(k, "."); !ok && i > 0 && external[k[:i]] != "" && c.params.Segments[k[:i]].Type == "" {
+				continue
+			}
+			if !ok {
+				errs = append(errs, ValidationError{File: l.source, Line: lines["options."+k], Key: k, Msg: "unknown option"})
+				continue
+			}
+			if _, err := o.Convert(l.params.Options[k]); err != nil {
+				errs = append(errs, ValidationError{File: l.source, Line: lines["options."+k], Key: k, Msg: err.Error()})
+			}
+		}
+		layerErrs := errs[first:]
+		sort.SliceStable(layerErrs, func(i, j int) bool { return layerErrs[i].Line < layerErrs[j].Line })
+	}
+	return errs
+}
+
+//declaredOptions returns the options declared by every available plugin by name
+func declaredOptions() map[string]plugin.Option {
+	options := make(map[string]plugin.Option)
+	for _, r := range availablePlugins {
+		_, opts := r.new().Help()
+		for _, o := range append(opts, errorPolicyOption(r.name)) {
+			options[o.Name] = o
+		}
+	}
+	return options
+}
+
+//keyLines returns the line of every key defined in data, a config in format, by its dotted
+//path (options.path.fullpath for a nested option). The first definition of a key wins
+func keyLines(format string, data []byte) map[string]int {
+	lines := make(map[string]int)
+	add := func(path []string, line int) {
+		key := strings.Join(path, ".")
+		if _, ok := lines[key]; !ok {
+			lines[key] = line
+		}
+	}
+	switch format {
+	case "yaml":
+		yamlKeyLines(data, add)
+	case "toml":
+		tomlKeyLines(data, add)
+	default:
+		jsonKeyLines(data, add)
+	}
+	return lines
+}
+
+//yamlKeyLines calls add with the path and line of every key in the yaml data, nested after
+//their indentation
+func yamlKeyLines(data []byte, add func([]string, int)) {
+	type level struct {
+		indent int
+		key    string
+	}
+	var stack []level
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "-") {
+			continue
+		}
+		key, ok := yamlKey(trimmed)
+		if !ok {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		path := make([]string, 0, len(stack)+1)
+		for _, l := range stack {
+			path = append(path, l.key)
+		}
+		add(append(path, key), i+1)
+		stack = append(stack, level{indent: indent, key: key})
+	}
+}
+
+//yamlKey returns the key defined in the yaml line s, if any
+func yamlKey(s string) (string, bool) {
+	if s[0] == '"' || s[0] == '\'' {
+		end := strings.IndexByte(s[1:], s[0])
+		if end < 0 {
+			return "", false
+		}
+		return s[1 : end+1], strings.HasPrefix(strings.TrimSpace(s[end+2:]), ":")
+	}
+	i := strings.Index(s, ":")
+	if i <= 0 || (i+1 < len(s) && s[i+1] != ' ') {
+		return "", false
+	}
+	return strings.TrimSpace(s[:i]), true
+}
+
+//tomlKeyLines calls add with the path and line of every key in the toml data, nested in
+//their table
+func tomlKeyLines(data []byte, add func([]string, int)) {
+	var table []string
+	multiline := false
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		//the lines of a multi-line string are not keys
+		inString := multiline
+		if strings.Count(line, `"""`)%2 == 1 {
+			multiline = !multiline
+		}
+		if inString || trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		if trimmed[0] == '[' {
+			if end := strings.LastIndex(trimmed, "]"); end > 0 {
+				table = tomlKeyPath(strings.Trim(trimmed[:end], "[] "))
+				add(table, i+1)
+			}
+			continue
+		}
+		eq := strings.IndexByte(trimmed, '=')
+		if trimmed[0] == '"' || trimmed[0] == '\'' {
+			if end := strings.IndexByte(trimmed[1:], trimmed[0]); end > 0 {
+				eq = strings.IndexByte(trimmed[end+2:], '=')
+				if eq >= 0 {
+					eq += end + 2
+				}
+			}
+		}
+		if eq <= 0 {
+			continue
+		}
+		add(append(append([]string{}, table...), tomlKeyPath(trimmed[:eq])...), i+1)
+	}
+}
+
+//tomlKeyPath splits a toml key in its parts: the dotted bare keys are nested, the quoted
+//ones are kept whole
+func tomlKeyPath(key string) []string {
+	var parts []string
+	for key = strings.TrimSpace(key); key != ""; key = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(key), ".")) {
+		var part string
+		if key[0] == '"' || key[0] == '\'' {
+			end := strings.IndexByte(key[1:], key[0])
+			if end < 0 {
+				return append(parts, key)
+			}
+			part, key = key[1:end+1], key[end+2:]
+		} else if i := strings.IndexByte(key, '.'); i >= 0 {
+			part, key = key[:i], key[i:]
+		} else {
+			part, key = key, ""
+		}
+		parts = append(parts, strings.TrimSpace(part))
+	}
+	return parts
+}
+
+//jsonKeyLines calls add with the path and line of every key in the json data, nested in
+//their objects
+func jsonKeyLines(data []byte, add func([]string, int)) {
+	//path has the keys of the enclosing objects, the first one being the document
+	var path []string
+	key, line := "", 1
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '\n':
+			line++
+		case '"':
+			start, startLine := i+1, line
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				} else if data[i] == '\n' {
+					line++
+				}
+			}
+			if i >= len(data) {
+				return
+			}
+			if rest := bytes.TrimLeft(data[i+1:], " \t\r\n"); len(rest) > 0 && rest[0] == ':' && len(path) > 0 {
+				key = string(data[start:i])
+				add(append(append([]string{}, path[1:]...), key), startLine)
+			}
+		case '{', '[':
+			path = append(path, key)
+			key = ""
+		case '}', ']':
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+	}
+}
+
+func sortedSegments(m map[string]error) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/josledp/goprompt/prompt"
+)
+
+const templateUsage = `usage: goprompt template check [-file template] [name]
+
+Checks a template: its syntax, that every plugin it loads exists and that every
+template it includes is defined. It checks the template file given with -file,
+the template called name (predefined or in ~/.config/goprompt/templates), or
+the configured one`
+
+//runTemplate runs the template subcommands and returns the exit code
+func runTemplate(userConfigFile string, args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, templateUsage)
+		return 1
+	}
+	fs := flag.NewFlagSet("template check", flag.ContinueOnError)
+	file := fs.String("file", "", "template file to check")
+	fs.Usage = func() { fmt.Fprintln(os.Stderr, templateUsage) }
+	if err := fs.Parse(args[1:]); err != nil {
+		return 1
+	}
+
+	templatesDir := os.Getenv("HOME") + "/.config/goprompt/templates"
+	templates, err := prompt.LoadTemplates(templatesDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	name, tmpl, err := templateToCheck(userConfigFile, *file, fs.Args(), templates, templatesDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	config, err := loadConfig(userConfigFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	errs := prompt.CheckTemplate(tmpl, templates, prompt.ConfiguredPlugins(config, os.Getenv("PATH")))
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+	fmt.Printf("%s: ok\n", name)
+	return 0
+}
+
+//templateToCheck returns the name and the text of the template to check
+func templateToCheck(userConfigFile, file string, args []string, templates map[string]string, templatesDir string) (string, string, error) {
+	if file != "" {
+		path := prompt.TemplateFilePath(file, templatesDir)
+		t, err := prompt.ReadTemplateFile(path)
+		return path, t, err
+	}
+	if len(args) > 0 {
+		t, ok := templates[args[0]]
+		if !ok {
+			return "", "", fmt.Errorf("template %s not found", args[0])
+		}
+		return args[0], t, nil
+	}
+
+	config, err := loadConfig(userConfigFile)
+	if err != nil {
+		return "", "", err
+	}
+	if t, ok := config.GetCustomTemplate(); ok {
+		return "custom_template", t, nil
+	}
+	if f, ok := config.GetTemplateFile(); ok {
+		path := prompt.TemplateFilePath(f, templatesDir)
+		t, err := prompt.ReadTemplateFile(path)
+		return path, t, err
+	}
+	name, ok := config.GetTemplate()
+	if !ok {
+		name = prompt.DefaultTemplate
+	}
+	t, ok := templates[name]
+	if !ok {
+		return "", "", fmt.Errorf("template %s not found", name)
+	}
+	return name, t, nil
+}